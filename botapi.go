@@ -0,0 +1,360 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HDIOES/anime-app/dao"
+)
+
+//Buttons of anime cards are language neutral, because TelegramCommandMessage has no locale
+const (
+	subscribeButtonText    = "🔔"
+	unsubscribeButtonText  = "🔕"
+	previousPageButtonText = "◀"
+	nextPageButtonText     = "▶"
+)
+
+//BotAPISender struct turns TelegramCommandMessage into Telegram Bot API calls
+type BotAPISender struct {
+	client   *http.Client
+	settings *Settings
+}
+
+//BotAPIResponse struct
+type BotAPIResponse struct {
+	Ok          bool                `json:"ok"`
+	ErrorCode   int                 `json:"error_code"`
+	Description string              `json:"description"`
+	Parameters  *ResponseParameters `json:"parameters"`
+}
+
+//messageNotModified is part of Bot API error description returned when edit does not change the message
+const messageNotModified = "message is not modified"
+
+//BotAPIError is returned when Bot API rejects the request
+type BotAPIError struct {
+	Method      string
+	ErrorCode   int
+	Description string
+}
+
+func (be *BotAPIError) Error() string {
+	return fmt.Sprintf("%s failed with code %d: %s", be.Method, be.ErrorCode, be.Description)
+}
+
+//ResponseParameters struct
+type ResponseParameters struct {
+	RetryAfter int `json:"retry_after"`
+}
+
+//InlineKeyboardMarkup struct
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+//InlineKeyboardButton struct
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data,omitempty"`
+	URL          string `json:"url,omitempty"`
+}
+
+//SendMessageRequest struct
+type SendMessageRequest struct {
+	ChatID      int64                 `json:"chat_id"`
+	Text        string                `json:"text"`
+	ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+//EditMessageTextRequest struct
+type EditMessageTextRequest struct {
+	ChatID      int64                 `json:"chat_id"`
+	MessageID   int64                 `json:"message_id"`
+	Text        string                `json:"text"`
+	ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+//EditMessageReplyMarkupRequest struct
+type EditMessageReplyMarkupRequest struct {
+	ChatID      int64                 `json:"chat_id"`
+	MessageID   int64                 `json:"message_id"`
+	ReplyMarkup *InlineKeyboardMarkup `json:"reply_markup"`
+}
+
+//AnswerCallbackQueryRequest struct
+type AnswerCallbackQueryRequest struct {
+	CallbackQueryID string `json:"callback_query_id"`
+	Text            string `json:"text,omitempty"`
+}
+
+//AnswerInlineQueryRequest struct
+type AnswerInlineQueryRequest struct {
+	InlineQueryID string                     `json:"inline_query_id"`
+	Results       []InlineQueryResultArticle `json:"results"`
+	NextOffset    string                     `json:"next_offset,omitempty"`
+	CacheTime     int                        `json:"cache_time"`
+	IsPersonal    bool                       `json:"is_personal"`
+}
+
+//InlineQueryResultArticle struct
+type InlineQueryResultArticle struct {
+	Type                string                `json:"type"`
+	ID                  string                `json:"id"`
+	Title               string                `json:"title"`
+	Description         string                `json:"description,omitempty"`
+	ThumbURL            string                `json:"thumb_url,omitempty"`
+	InputMessageContent InputMessageContent   `json:"input_message_content"`
+	ReplyMarkup         *InlineKeyboardMarkup `json:"reply_markup,omitempty"`
+}
+
+//InputMessageContent struct
+type InputMessageContent struct {
+	MessageText string `json:"message_text"`
+}
+
+//Send func
+func (bs *BotAPISender) Send(ntsMessage *TelegramCommandMessage) error {
+	switch ntsMessage.Type {
+	case startType, defaultType, confirmationType, languageType, notificationType, reminderType, digestType:
+		return bs.call("sendMessage", &SendMessageRequest{
+			ChatID:      ntsMessage.TelegramID,
+			Text:        messageText(ntsMessage),
+			ReplyMarkup: messageKeyboard(ntsMessage),
+		})
+	case listType:
+		if ntsMessage.CallbackQueryID == "" {
+			return bs.call("sendMessage", &SendMessageRequest{
+				ChatID:      ntsMessage.TelegramID,
+				Text:        messageText(ntsMessage),
+				ReplyMarkup: messageKeyboard(ntsMessage),
+			})
+		}
+		if err := bs.edit("editMessageText", &EditMessageTextRequest{
+			ChatID:      ntsMessage.ChatID,
+			MessageID:   ntsMessage.MessageID,
+			Text:        messageText(ntsMessage),
+			ReplyMarkup: messageKeyboard(ntsMessage),
+		}); err != nil {
+			return err
+		}
+		return bs.answerCallbackQuery(ntsMessage.CallbackQueryID, "")
+	case answerQueryType:
+		return bs.call("answerInlineQuery", bs.answerInlineQueryRequest(ntsMessage))
+	case subscribeType, unsubscribeType:
+		if err := bs.edit("editMessageReplyMarkup", &EditMessageReplyMarkupRequest{
+			ChatID:      ntsMessage.ChatID,
+			MessageID:   ntsMessage.MessageID,
+			ReplyMarkup: subscriptionKeyboard(ntsMessage.InternalAnimeID, ntsMessage.Type == subscribeType),
+		}); err != nil {
+			return err
+		}
+		return bs.answerCallbackQuery(ntsMessage.CallbackQueryID, "")
+	case editTextType:
+		if err := bs.edit("editMessageText", &EditMessageTextRequest{
+			ChatID:    ntsMessage.ChatID,
+			MessageID: ntsMessage.MessageID,
+			Text:      ntsMessage.Text,
+		}); err != nil {
+			return err
+		}
+		return bs.answerCallbackQuery(ntsMessage.CallbackQueryID, "")
+	case answerCallbackType:
+		return bs.answerCallbackQuery(ntsMessage.CallbackQueryID, ntsMessage.Text)
+	}
+	return errors.Errorf("Message type %s is not supported by Bot API sender", ntsMessage.Type)
+}
+
+//Encode func stores message in legacy JSON contract
+func (bs *BotAPISender) Encode(ntsMessage *TelegramCommandMessage) (*dao.OutboxMessage, error) {
+	data, err := json.Marshal(ntsMessage)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &dao.OutboxMessage{ChatID: messageChatID(ntsMessage), ContentType: jsonContentType, Data: data}, nil
+}
+
+//SendEncoded func drops messages which were encoded for NATS sender before sender mode was switched
+func (bs *BotAPISender) SendEncoded(message *dao.OutboxMessage) error {
+	ntsMessage := &TelegramCommandMessage{}
+	if message.ContentType != jsonContentType {
+		HandleError(errors.Errorf("Outbox message with content type %s dropped", message.ContentType))
+		return nil
+	}
+	if err := json.Unmarshal(message.Data, ntsMessage); err != nil {
+		HandleError(errors.WithStack(err))
+		return nil
+	}
+	return bs.Send(ntsMessage)
+}
+
+func (bs *BotAPISender) answerCallbackQuery(callbackQueryID, text string) error {
+	return bs.call("answerCallbackQuery", &AnswerCallbackQueryRequest{
+		CallbackQueryID: callbackQueryID,
+		Text:            text,
+	})
+}
+
+func (bs *BotAPISender) answerInlineQueryRequest(ntsMessage *TelegramCommandMessage) *AnswerInlineQueryRequest {
+	request := &AnswerInlineQueryRequest{
+		InlineQueryID: ntsMessage.InlineQueryID,
+		Results:       make([]InlineQueryResultArticle, 0, len(ntsMessage.InlineAnimes)),
+		NextOffset:    ntsMessage.NextOffset,
+		IsPersonal:    true,
+	}
+	for _, inlineAnime := range ntsMessage.InlineAnimes {
+		article := InlineQueryResultArticle{
+			Type:                "article",
+			ID:                  strconv.FormatInt(inlineAnime.InternalID, 10),
+			Title:               inlineAnime.AnimeName,
+			Description:         inlineAnime.AirTime,
+			ThumbURL:            inlineAnime.AnimeThumbnailPicURL,
+			InputMessageContent: InputMessageContent{MessageText: inlineAnime.AnimeName},
+		}
+		if bs.settings.BotUsername != "" {
+			article.ReplyMarkup = &InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{{{
+				Text: subscribeButtonText,
+				URL:  fmt.Sprintf("https://t.me/%s?start=%d", bs.settings.BotUsername, inlineAnime.InternalID),
+			}}}}
+		}
+		request.Results = append(request.Results, article)
+	}
+	return request
+}
+
+//edit func calls Bot API method which edits a message. Edit repeated after answerCallbackQuery failed
+//is rejected because the message is already edited, so such rejection is taken as success
+func (bs *BotAPISender) edit(method string, request interface{}) error {
+	err := bs.call(method, request)
+	var botAPIErr *BotAPIError
+	if errors.As(err, &botAPIErr) && botAPIErr.ErrorCode == http.StatusBadRequest && strings.Contains(botAPIErr.Description, messageNotModified) {
+		return nil
+	}
+	return err
+}
+
+//call func posts request to Bot API method. Rejected requests are returned as ClientError wrapping BotAPIError,
+//throttled requests and server failures as TransientError, which wraps RetryAfterError when Telegram sets retry_after
+func (bs *BotAPISender) call(method string, request interface{}) error {
+	body, marshalErr := json.Marshal(request)
+	if marshalErr != nil {
+		return errors.WithStack(marshalErr)
+	}
+	methodURL := bs.settings.TelegramURL + "/bot" + bs.settings.BotToken + "/" + method
+	response, responseErr := bs.client.Post(methodURL, "application/json", bytes.NewReader(body))
+	if responseErr != nil {
+		return newTransientError(redactToken(responseErr, bs.settings.BotToken))
+	}
+	defer response.Body.Close()
+	respReader, logRespErr := logResponse(response)
+	if logRespErr != nil {
+		return logRespErr
+	}
+	botAPIResponse := &BotAPIResponse{}
+	if decodeErr := json.NewDecoder(respReader).Decode(botAPIResponse); decodeErr != nil {
+		return newTransientError(decodeErr)
+	}
+	if botAPIResponse.Ok {
+		return nil
+	}
+	callErr := errors.WithStack(&BotAPIError{Method: method, ErrorCode: botAPIResponse.ErrorCode, Description: botAPIResponse.Description})
+	if botAPIResponse.Parameters != nil && botAPIResponse.Parameters.RetryAfter > 0 {
+		return newTransientError(&RetryAfterError{
+			RetryAfter: time.Duration(botAPIResponse.Parameters.RetryAfter) * time.Second,
+			Err:        callErr,
+		})
+	}
+	if botAPIResponse.ErrorCode == http.StatusTooManyRequests || botAPIResponse.ErrorCode >= http.StatusInternalServerError {
+		return newTransientError(callErr)
+	}
+	return newClientError(callErr)
+}
+
+//redactToken func hides bot token in URL of failed request, so it does not get to logs
+func redactToken(err error, token string) error {
+	var urlErr *url.Error
+	if token == "" || !errors.As(err, &urlErr) {
+		return err
+	}
+	return &url.Error{Op: urlErr.Op, URL: strings.ReplaceAll(urlErr.URL, token, "<token>"), Err: urlErr.Err}
+}
+
+//messageText func appends anime cards to text of the message
+func messageText(ntsMessage *TelegramCommandMessage) string {
+	lines := make([]string, 0, len(ntsMessage.InlineAnimes)+2)
+	if ntsMessage.Text != "" {
+		lines = append(lines, ntsMessage.Text)
+	}
+	if ntsMessage.InlineAnime != nil {
+		lines = append(lines, animeText(ntsMessage.InlineAnime))
+	}
+	for i := range ntsMessage.InlineAnimes {
+		lines = append(lines, animeText(&ntsMessage.InlineAnimes[i]))
+	}
+	return strings.Join(lines, "\n\n")
+}
+
+func animeText(inlineAnime *InlineAnime) string {
+	text := inlineAnime.AnimeName
+	if inlineAnime.AirTime != "" {
+		text += "\n" + inlineAnime.AirTime
+	} else if inlineAnime.NextEpisodeAt != nil {
+		text += "\n" + inlineAnime.NextEpisodeAt.Format(airTimeLayout)
+	}
+	return text
+}
+
+//messageKeyboard func builds inline keyboard with subscription button of the anime card,
+//buttons of the message and page buttons of /list
+func messageKeyboard(ntsMessage *TelegramCommandMessage) *InlineKeyboardMarkup {
+	keyboard := make([][]InlineKeyboardButton, 0)
+	if ntsMessage.InlineAnime != nil && ntsMessage.Type == startType {
+		keyboard = append(keyboard, subscriptionKeyboard(ntsMessage.InlineAnime.InternalID, ntsMessage.InlineAnime.UserHasSubscription).InlineKeyboard...)
+	}
+	if len(ntsMessage.InlineButtons) > 0 {
+		row := make([]InlineKeyboardButton, 0, len(ntsMessage.InlineButtons))
+		for _, inlineButton := range ntsMessage.InlineButtons {
+			row = append(row, InlineKeyboardButton{Text: inlineButton.Text, CallbackData: inlineButton.CallbackData})
+		}
+		keyboard = append(keyboard, row)
+	}
+	if ntsMessage.Type == listType {
+		row := make([]InlineKeyboardButton, 0, 2)
+		if ntsMessage.Page > 0 {
+			row = append(row, InlineKeyboardButton{Text: previousPageButtonText, CallbackData: fmt.Sprintf("%s %d", listCallback, ntsMessage.Page-1)})
+		}
+		if ntsMessage.HasNextPage {
+			row = append(row, InlineKeyboardButton{Text: nextPageButtonText, CallbackData: fmt.Sprintf("%s %d", listCallback, ntsMessage.Page+1)})
+		}
+		if len(row) > 0 {
+			keyboard = append(keyboard, row)
+		}
+	}
+	if len(keyboard) == 0 {
+		return nil
+	}
+	return &InlineKeyboardMarkup{InlineKeyboard: keyboard}
+}
+
+//subscriptionKeyboard func returns unsubscribe button for subscribed anime and subscribe button otherwise
+func subscriptionKeyboard(internalAnimeID int64, subscribed bool) *InlineKeyboardMarkup {
+	button := InlineKeyboardButton{
+		Text:         subscribeButtonText,
+		CallbackData: fmt.Sprintf("%s %d", subscribeCallback, internalAnimeID),
+	}
+	if subscribed {
+		button = InlineKeyboardButton{
+			Text:         unsubscribeButtonText,
+			CallbackData: fmt.Sprintf("%s %d", unsubscribeCallback, internalAnimeID),
+		}
+	}
+	return &InlineKeyboardMarkup{InlineKeyboard: [][]InlineKeyboardButton{{button}}}
+}
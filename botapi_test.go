@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HDIOES/anime-app/dao"
+)
+
+const testBotToken = "123456:secret-token"
+
+type botAPICall struct {
+	method  string
+	request map[string]interface{}
+}
+
+//fakeBotAPI func starts Bot API stand-in which records calls and answers each of them with respond
+func fakeBotAPI(t *testing.T, respond func(method string) (int, string)) (*BotAPISender, *[]botAPICall) {
+	calls := make([]botAPICall, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefix := "/bot" + testBotToken + "/"
+		if !strings.HasPrefix(r.URL.Path, prefix) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		method := strings.TrimPrefix(r.URL.Path, prefix)
+		body, _ := ioutil.ReadAll(r.Body)
+		request := make(map[string]interface{})
+		if err := json.Unmarshal(body, &request); err != nil {
+			t.Errorf("%s request is not JSON: %v", method, err)
+		}
+		calls = append(calls, botAPICall{method: method, request: request})
+		status, response := respond(method)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	sender := &BotAPISender{client: server.Client(), settings: &Settings{TelegramURL: server.URL, BotToken: testBotToken}}
+	return sender, &calls
+}
+
+func okResponse(method string) (int, string) {
+	return http.StatusOK, `{"ok":true,"result":true}`
+}
+
+func TestBotAPISenderSendsMessage(t *testing.T) {
+	sender, calls := fakeBotAPI(t, okResponse)
+	if err := sender.Send(&TelegramCommandMessage{Type: startType, TelegramID: 42, Text: "Hello"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(*calls) != 1 || (*calls)[0].method != "sendMessage" {
+		t.Fatalf("calls are %v, want sendMessage", *calls)
+	}
+	request := (*calls)[0].request
+	if request["chat_id"] != float64(42) || request["text"] != "Hello" {
+		t.Fatalf("sendMessage request is %v", request)
+	}
+}
+
+func TestBotAPISenderAnswersCallbackAfterSubscription(t *testing.T) {
+	sender, calls := fakeBotAPI(t, okResponse)
+	ntsMessage := &TelegramCommandMessage{Type: subscribeType, ChatID: 42, MessageID: 5, CallbackQueryID: "query", InternalAnimeID: 7}
+	if err := sender.Send(ntsMessage); err != nil {
+		t.Fatal(err)
+	}
+	if len(*calls) != 2 || (*calls)[0].method != "editMessageReplyMarkup" || (*calls)[1].method != "answerCallbackQuery" {
+		t.Fatalf("calls are %v, want editMessageReplyMarkup and answerCallbackQuery", *calls)
+	}
+	if (*calls)[1].request["callback_query_id"] != "query" {
+		t.Fatalf("answerCallbackQuery request is %v", (*calls)[1].request)
+	}
+}
+
+func TestBotAPISenderRepeatsSubscriptionAfterCallbackAnswerFailed(t *testing.T) {
+	answerFailed := false
+	edited := false
+	sender, calls := fakeBotAPI(t, func(method string) (int, string) {
+		if method == "editMessageReplyMarkup" && edited {
+			return http.StatusBadRequest, `{"ok":false,"error_code":400,"description":"Bad Request: message is not modified: specified new message content and reply markup are exactly the same"}`
+		}
+		edited = edited || method == "editMessageReplyMarkup"
+		if method == "answerCallbackQuery" && !answerFailed {
+			answerFailed = true
+			return http.StatusBadGateway, `{"ok":false,"error_code":502,"description":"Bad Gateway"}`
+		}
+		return okResponse(method)
+	})
+	ntsMessage := &TelegramCommandMessage{Type: subscribeType, ChatID: 42, MessageID: 5, CallbackQueryID: "query", InternalAnimeID: 7}
+	if err := sender.Send(ntsMessage); statusCode(err) != http.StatusServiceUnavailable {
+		t.Fatalf("failed answer returned %v, want transient error", err)
+	}
+	if err := sender.Send(ntsMessage); err != nil {
+		t.Fatalf("repeated subscription returned %v", err)
+	}
+	if len(*calls) != 4 || (*calls)[3].method != "answerCallbackQuery" {
+		t.Fatalf("calls are %v, want callback query answered on repeat", *calls)
+	}
+}
+
+func TestBotAPISenderClassifiesFailures(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		response   string
+		code       int
+		retryAfter time.Duration
+	}{
+		{
+			name:     "blocked",
+			status:   http.StatusForbidden,
+			response: `{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`,
+			code:     http.StatusOK,
+		},
+		{
+			name:       "retry after",
+			status:     http.StatusTooManyRequests,
+			response:   `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 3","parameters":{"retry_after":3}}`,
+			code:       http.StatusServiceUnavailable,
+			retryAfter: 3 * time.Second,
+		},
+		{
+			name:     "server failure",
+			status:   http.StatusBadGateway,
+			response: `{"ok":false,"error_code":502,"description":"Bad Gateway"}`,
+			code:     http.StatusServiceUnavailable,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sender, _ := fakeBotAPI(t, func(method string) (int, string) {
+				return test.status, test.response
+			})
+			err := sender.Send(&TelegramCommandMessage{Type: defaultType, TelegramID: 42, Text: "Hello"})
+			if code := statusCode(err); code != test.code {
+				t.Fatalf("status code of %v is %d, want %d", err, code, test.code)
+			}
+			var botAPIErr *BotAPIError
+			if !errors.As(err, &botAPIErr) || botAPIErr.ErrorCode != test.status {
+				t.Fatalf("%v does not carry Bot API error code %d", err, test.status)
+			}
+			var retryAfterErr *RetryAfterError
+			if errors.As(err, &retryAfterErr) != (test.retryAfter > 0) || test.retryAfter > 0 && retryAfterErr.RetryAfter != test.retryAfter {
+				t.Fatalf("%v has unexpected retry_after", err)
+			}
+		})
+	}
+}
+
+func TestBotAPISenderRedactsTokenOfFailedRequest(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	sender := &BotAPISender{client: server.Client(), settings: &Settings{TelegramURL: server.URL, BotToken: testBotToken}}
+	err := sender.Send(&TelegramCommandMessage{Type: defaultType, TelegramID: 42, Text: "Hello"})
+	if code := statusCode(err); code != http.StatusServiceUnavailable {
+		t.Fatalf("status code of %v is %d, want %d", err, code, http.StatusServiceUnavailable)
+	}
+	if strings.Contains(err.Error(), testBotToken) {
+		t.Fatalf("error contains bot token: %v", err)
+	}
+}
+
+func TestOutboxRelayDeadLettersRejectedMessage(t *testing.T) {
+	sender, calls := fakeBotAPI(t, func(method string) (int, string) {
+		return http.StatusBadRequest, `{"ok":false,"error_code":400,"description":"Bad Request: message to edit not found"}`
+	})
+	relay := &OutboxRelay{sender: sender}
+	message, encodeErr := sender.Encode(&TelegramCommandMessage{Type: editTextType, ChatID: 42, MessageID: 5, CallbackQueryID: "query", Text: "Done"})
+	if encodeErr != nil {
+		t.Fatal(encodeErr)
+	}
+	err := relay.publish(message)
+	var deadLetterErr *dao.DeadLetterError
+	if !errors.As(err, &deadLetterErr) {
+		t.Fatalf("rejected message returned %v, want DeadLetterError", err)
+	}
+	if len(*calls) != 1 {
+		t.Fatalf("%d calls made, want 1", len(*calls))
+	}
+}
+
+func TestOutboxRelayRetriesTransientFailure(t *testing.T) {
+	sender, _ := fakeBotAPI(t, func(method string) (int, string) {
+		return http.StatusInternalServerError, `{"ok":false,"error_code":500,"description":"Internal Server Error"}`
+	})
+	relay := &OutboxRelay{sender: sender}
+	message, encodeErr := sender.Encode(&TelegramCommandMessage{Type: defaultType, TelegramID: 42, Text: "Hello"})
+	if encodeErr != nil {
+		t.Fatal(encodeErr)
+	}
+	err := relay.publish(message)
+	var deadLetterErr *dao.DeadLetterError
+	if err == nil || errors.As(err, &deadLetterErr) {
+		t.Fatalf("transient failure returned %v, want error which is retried", err)
+	}
+}
@@ -2,7 +2,6 @@ package dao
 
 import (
 	sql "database/sql"
-	"fmt"
 	"time"
 
 	"github.com/pkg/errors"
@@ -32,16 +31,40 @@ type UserAnimeDTO struct {
 
 const pageSize = 50
 
+const subscriptionsPageSize = 10
+
 const (
 	findAnimeByInternalIDAndByInternalUserIDSQL = "SELECT ANS.ID, ANS.EXTERNALID, ANS.RUSNAME, ANS.ENGNAME, ANS.IMAGEURL, ANS.NEXT_EPISODE_AT, ANS.NOTIFICATION_SENT, SS.ANIME_ID FROM ANIMES AS ANS" +
 		" LEFT JOIN SUBSCRIPTIONS AS SS ON (ANS.ID = SS.ANIME_ID AND SS.TELEGRAM_USER_ID = $1) WHERE ANS.ID = $2"
-	findAllAnimesBySentenceAndInternalUserIDSQL = "SELECT ANS.ID, ANS.EXTERNALID, ANS.RUSNAME, ANS.ENGNAME, ANS.IMAGEURL, ANS.NEXT_EPISODE_AT, ANS.NOTIFICATION_SENT, SS.ANIME_ID FROM ANIMES AS ANS" +
-		" LEFT JOIN SUBSCRIPTIONS AS SS ON (ANS.ID = SS.ANIME_ID AND SS.TELEGRAM_USER_ID = $1) WHERE LOWER(ANS.ENGNAME) LIKE $2 OR LOWER(ANS.RUSNAME) LIKE $2 ORDER BY SS.ANIME_ID LIMIT $3"
-	findUserByExternalIDSQL = "SELECT ID, TELEGRAM_USER_ID, TELEGRAM_USERNAME FROM TELEGRAM_USERS WHERE TELEGRAM_USER_ID = $1"
-	findSubscriptionSQL     = "SELECT TELEGRAM_USER_ID, ANIME_ID FROM SUBSCRIPTIONS WHERE TELEGRAM_USER_ID = $1 AND ANIME_ID = $2"
-	insertUserSQL           = "INSERT INTO TELEGRAM_USERS (TELEGRAM_USER_ID, TELEGRAM_USERNAME) VALUES($1, $2) RETURNING ID"
-	insertSubscriptionSQL   = "INSERT INTO SUBSCRIPTIONS (TELEGRAM_USER_ID, ANIME_ID) VALUES($1, $2)"
-	deleteSubscriptionSQL   = "DELETE FROM SUBSCRIPTIONS WHERE TELEGRAM_USER_ID = $1 AND ANIME_ID = $2"
+	findSubscriptionSQL                     = "SELECT TELEGRAM_USER_ID, ANIME_ID FROM SUBSCRIPTIONS WHERE TELEGRAM_USER_ID = $1 AND ANIME_ID = $2"
+	insertSubscriptionSQL                   = "INSERT INTO SUBSCRIPTIONS (TELEGRAM_USER_ID, ANIME_ID) VALUES($1, $2)"
+	deleteSubscriptionSQL                   = "DELETE FROM SUBSCRIPTIONS WHERE TELEGRAM_USER_ID = $1 AND ANIME_ID = $2"
+	findSubscribedAnimesByInternalUserIDSQL = "SELECT ANS.ID, ANS.EXTERNALID, ANS.RUSNAME, ANS.ENGNAME, ANS.IMAGEURL, ANS.NEXT_EPISODE_AT, ANS.NOTIFICATION_SENT FROM SUBSCRIPTIONS AS SS" +
+		" JOIN ANIMES AS ANS ON (SS.ANIME_ID = ANS.ID) WHERE SS.TELEGRAM_USER_ID = $1 ORDER BY ANS.NEXT_EPISODE_AT, ANS.ID LIMIT $2 OFFSET $3"
+	findReleasedAnimeSQL = "SELECT ID, EXTERNALID, RUSNAME, ENGNAME, IMAGEURL, NEXT_EPISODE_AT, NOTIFICATION_SENT FROM ANIMES" +
+		" WHERE NEXT_EPISODE_AT <= NOW() AND NOTIFICATION_SENT = FALSE ORDER BY NEXT_EPISODE_AT LIMIT 1 FOR UPDATE SKIP LOCKED"
+	findSubscribersByAnimeIDSQL = "SELECT TU.ID, TU.TELEGRAM_USER_ID, TU.TELEGRAM_USERNAME, TU.ACTIVE, TU.LOCALE, TU.TIMEZONE," +
+		" COALESCE(UP.QUIET_FROM, 0), COALESCE(UP.QUIET_TO, 0) FROM SUBSCRIPTIONS AS SS" +
+		" JOIN TELEGRAM_USERS AS TU ON (SS.TELEGRAM_USER_ID = TU.ID) LEFT JOIN USER_PREFERENCES AS UP ON (UP.TELEGRAM_USER_ID = TU.ID)" +
+		" WHERE SS.ANIME_ID = $1 AND TU.ACTIVE = TRUE AND TU.BLOCKED = FALSE AND COALESCE(UP.NOTIFICATION_MODE, 'instant') = 'instant'"
+	deleteAllSubscriptionsSQL = "DELETE FROM SUBSCRIPTIONS WHERE TELEGRAM_USER_ID = $1"
+	updateUserActiveSQL       = "UPDATE TELEGRAM_USERS SET ACTIVE = $2 WHERE ID = $1"
+	updateUserBlockedSQL      = "UPDATE TELEGRAM_USERS SET BLOCKED = $2 WHERE TELEGRAM_USER_ID = $1"
+	updateUserLocaleSQL       = "UPDATE TELEGRAM_USERS SET LOCALE = $2 WHERE ID = $1"
+	updateUserTimezoneSQL     = "UPDATE TELEGRAM_USERS SET TIMEZONE = $2 WHERE ID = $1"
+	updateNotificationSentSQL = "UPDATE ANIMES SET NOTIFICATION_SENT = TRUE WHERE ID = $1"
+	findAnimeScheduleSQL      = "SELECT NEXT_EPISODE_AT FROM ANIMES WHERE EXTERNALID = $1 FOR UPDATE"
+	//upsertAnimeSQL keeps NEXT_EPISODE_AT of released episode which is not notified yet, the notifier moves it on
+	upsertAnimeSQL = "INSERT INTO ANIMES (EXTERNALID, RUSNAME, ENGNAME, IMAGEURL, NEXT_EPISODE_AT, NOTIFICATION_SENT) VALUES($1, $2, $3, $4, $5, FALSE)" +
+		" ON CONFLICT (EXTERNALID) DO UPDATE SET RUSNAME = EXCLUDED.RUSNAME, ENGNAME = EXCLUDED.ENGNAME, IMAGEURL = EXCLUDED.IMAGEURL," +
+		" NEXT_EPISODE_AT = CASE WHEN ANIMES.NEXT_EPISODE_AT <= NOW() AND NOT ANIMES.NOTIFICATION_SENT THEN ANIMES.NEXT_EPISODE_AT ELSE EXCLUDED.NEXT_EPISODE_AT END," +
+		" NOTIFICATION_SENT = CASE WHEN ANIMES.NEXT_EPISODE_AT <= NOW() AND NOT ANIMES.NOTIFICATION_SENT OR ANIMES.NEXT_EPISODE_AT = EXCLUDED.NEXT_EPISODE_AT" +
+		" THEN ANIMES.NOTIFICATION_SENT ELSE FALSE END" +
+		" RETURNING ID, NEXT_EPISODE_AT"
+	//upsertUserSQL keeps locale which was already set, XMAX of the returned row is zero only when it was inserted
+	upsertUserSQL = "INSERT INTO TELEGRAM_USERS (TELEGRAM_USER_ID, TELEGRAM_USERNAME, LOCALE) VALUES($1, $2, $3)" +
+		" ON CONFLICT (TELEGRAM_USER_ID) DO UPDATE SET LOCALE = COALESCE(NULLIF(TELEGRAM_USERS.LOCALE, ''), EXCLUDED.LOCALE)" +
+		" RETURNING ID, TELEGRAM_USER_ID, TELEGRAM_USERNAME, ACTIVE, LOCALE, TIMEZONE, XMAX = 0"
 )
 
 //FindByUserIDAndInternalID func
@@ -66,9 +89,219 @@ func (adao *AnimeDAO) FindByUserIDAndInternalID(internalUserID, internalAnimeID
 	return nil, nil
 }
 
-//ReadUserAnimes func
-func (adao *AnimeDAO) ReadUserAnimes(internalUserID int64, sentence string) ([]UserAnimeDTO, error) {
-	return adao.readUserAnimesBySQL(internalUserID, sentence, findAllAnimesBySentenceAndInternalUserIDSQL)
+//NotifyReleased func processes every anime whose NEXT_EPISODE_AT has passed and NOTIFICATION_SENT is false.
+//Each anime is handled in its own transaction: NOTIFICATION_SENT is flipped and notify is called for every subscriber,
+//returned notifications are written to outbox or held before commit, so each of them is delivered once by the relay
+//and a failed notify leaves the anime pending for the next run. Nil notification means nothing to deliver.
+//The release is recorded for digests and only subscribers in instant mode are passed to notify
+func (adao *AnimeDAO) NotifyReleased(notify func(anime *AnimeDTO, subscriber *SubscriberDTO) (*Notification, error)) (int, error) {
+	count := 0
+	for {
+		processed, err := adao.notifyNextReleased(notify)
+		if err != nil {
+			return count, err
+		}
+		if !processed {
+			return count, nil
+		}
+		count++
+	}
+}
+
+func (adao *AnimeDAO) notifyNextReleased(notify func(anime *AnimeDTO, subscriber *SubscriberDTO) (*Notification, error)) (bool, error) {
+	tx, txErr := adao.Db.Begin()
+	if txErr != nil {
+		return false, errors.WithStack(txErr)
+	}
+	processed, notifyErr := adao.notifyReleased(tx, notify)
+	if notifyErr != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return false, errors.WithStack(rollbackErr)
+		}
+		return false, notifyErr
+	}
+	if commitErr := tx.Commit(); commitErr != nil {
+		return false, errors.WithStack(commitErr)
+	}
+	return processed, nil
+}
+
+func (adao *AnimeDAO) notifyReleased(tx *sql.Tx, notify func(anime *AnimeDTO, subscriber *SubscriberDTO) (*Notification, error)) (bool, error) {
+	animeDTO, findErr := adao.findReleased(tx)
+	if findErr != nil {
+		return false, findErr
+	}
+	if animeDTO == nil {
+		return false, nil
+	}
+	subscribers, subscribersErr := adao.findSubscribers(tx, animeDTO.ID)
+	if subscribersErr != nil {
+		return false, subscribersErr
+	}
+	if updateErr := adao.markNotificationSent(tx, animeDTO.ID); updateErr != nil {
+		return false, updateErr
+	}
+	if resetErr := resetRemindersSent(tx, animeDTO.ID); resetErr != nil {
+		return false, resetErr
+	}
+	if releaseErr := insertEpisodeRelease(tx, animeDTO.ID, animeDTO.NextEpisodeAt); releaseErr != nil {
+		return false, releaseErr
+	}
+	for i := range subscribers {
+		notification, notifyErr := notify(animeDTO, &subscribers[i])
+		if notifyErr != nil {
+			return false, notifyErr
+		}
+		if insertErr := insertNotification(tx, subscribers[i].ID, notification); insertErr != nil {
+			return false, insertErr
+		}
+	}
+	return true, nil
+}
+
+func (adao *AnimeDAO) findReleased(tx *sql.Tx) (*AnimeDTO, error) {
+	sqlStatement, stmtErr := tx.Prepare(findReleasedAnimeSQL)
+	if stmtErr != nil {
+		return nil, errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	result, resErr := sqlStatement.Query()
+	if resErr != nil {
+		return nil, errors.WithStack(resErr)
+	}
+	defer result.Close()
+	if result.Next() {
+		return scanAsAnime(result)
+	}
+	return nil, nil
+}
+
+func (adao *AnimeDAO) findSubscribers(tx *sql.Tx, internalAnimeID int64) ([]SubscriberDTO, error) {
+	sqlStatement, stmtErr := tx.Prepare(findSubscribersByAnimeIDSQL)
+	if stmtErr != nil {
+		return nil, errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	result, resErr := sqlStatement.Query(internalAnimeID)
+	if resErr != nil {
+		return nil, errors.WithStack(resErr)
+	}
+	defer result.Close()
+	subscribers := make([]SubscriberDTO, 0)
+	for result.Next() {
+		subscriberDTO, scanErr := scanAsSubscriber(result)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		subscribers = append(subscribers, *subscriberDTO)
+	}
+	return subscribers, nil
+}
+
+func (adao *AnimeDAO) markNotificationSent(tx *sql.Tx, internalAnimeID int64) error {
+	sqlStatement, stmtErr := tx.Prepare(updateNotificationSentSQL)
+	if stmtErr != nil {
+		return errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	if _, resErr := sqlStatement.Exec(internalAnimeID); resErr != nil {
+		return errors.WithStack(resErr)
+	}
+	return nil
+}
+
+//Upsert func inserts anime or updates existing one with the same EXTERNALID.
+//NOTIFICATION_SENT and REMINDER_SENT of subscriptions are reset when NEXT_EPISODE_AT is moved.
+//Released episode which is not notified yet keeps its NEXT_EPISODE_AT until the notifier handles it
+func (adao *AnimeDAO) Upsert(animeDTO *AnimeDTO) error {
+	tx, txErr := adao.Db.Begin()
+	if txErr != nil {
+		return errors.WithStack(txErr)
+	}
+	if upsertErr := adao.upsert(tx, animeDTO); upsertErr != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return errors.WithStack(rollbackErr)
+		}
+		return upsertErr
+	}
+	if commitErr := tx.Commit(); commitErr != nil {
+		return errors.WithStack(commitErr)
+	}
+	return nil
+}
+
+func (adao *AnimeDAO) upsert(tx *sql.Tx, animeDTO *AnimeDTO) error {
+	scheduledAt, findErr := adao.findSchedule(tx, animeDTO.ExternalID)
+	if findErr != nil {
+		return findErr
+	}
+	sqlStatement, stmtErr := tx.Prepare(upsertAnimeSQL)
+	if stmtErr != nil {
+		return errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	var ID int64
+	var nextEpisodeAt PqTime
+	scanErr := sqlStatement.QueryRow(animeDTO.ExternalID, animeDTO.RusName, animeDTO.EngName, animeDTO.ImageURL, animeDTO.NextEpisodeAt).Scan(&ID, &nextEpisodeAt)
+	if scanErr != nil {
+		return errors.WithStack(scanErr)
+	}
+	if scheduledAt.Valid && !scheduledAt.Time.Equal(nextEpisodeAt.Time) {
+		return resetRemindersSent(tx, ID)
+	}
+	return nil
+}
+
+//findSchedule func locks anime with the EXTERNALID and returns its NEXT_EPISODE_AT, which is not valid when there is no such anime
+func (adao *AnimeDAO) findSchedule(tx *sql.Tx, externalID string) (PqTime, error) {
+	var nextEpisodeAt PqTime
+	sqlStatement, stmtErr := tx.Prepare(findAnimeScheduleSQL)
+	if stmtErr != nil {
+		return nextEpisodeAt, errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	scanErr := sqlStatement.QueryRow(externalID).Scan(&nextEpisodeAt)
+	if scanErr != nil && scanErr != sql.ErrNoRows {
+		return nextEpisodeAt, errors.WithStack(scanErr)
+	}
+	return nextEpisodeAt, nil
+}
+
+func scanAsAnime(result *sql.Rows) (*AnimeDTO, error) {
+	var ID sql.NullInt64
+	var externalID sql.NullString
+	var rusname sql.NullString
+	var engname sql.NullString
+	var imageURL sql.NullString
+	var nextEpisodeAt PqTime
+	var notificationSent sql.NullBool
+	scanErr := result.Scan(&ID, &externalID, &rusname, &engname, &imageURL, &nextEpisodeAt, &notificationSent)
+	if scanErr != nil {
+		return nil, errors.WithStack(scanErr)
+	}
+	animeDTO := AnimeDTO{}
+	if ID.Valid {
+		animeDTO.ID = ID.Int64
+	}
+	if externalID.Valid {
+		animeDTO.ExternalID = externalID.String
+	}
+	if rusname.Valid {
+		animeDTO.RusName = rusname.String
+	}
+	if engname.Valid {
+		animeDTO.EngName = engname.String
+	}
+	if imageURL.Valid {
+		animeDTO.ImageURL = imageURL.String
+	}
+	if nextEpisodeAt.Valid {
+		animeDTO.NextEpisodeAt = nextEpisodeAt.Time
+	}
+	if notificationSent.Valid {
+		animeDTO.NotificationSent = notificationSent.Bool
+	}
+	return &animeDTO, nil
 }
 
 func (adao *AnimeDAO) scanAsUserAnime(result *sql.Rows) (*UserAnimeDTO, error) {
@@ -110,26 +343,30 @@ func (adao *AnimeDAO) scanAsUserAnime(result *sql.Rows) (*UserAnimeDTO, error) {
 	return &userAnimeDTO, nil
 }
 
-func (adao *AnimeDAO) readUserAnimesBySQL(internalUserID int64, sentence string, sqlStr string) ([]UserAnimeDTO, error) {
+//readUserAnimesBySQL func expects sqlStr to take LIMIT and OFFSET as its last two parameters
+func (adao *AnimeDAO) readUserAnimesBySQL(sqlStr string, offset int64, args ...interface{}) ([]UserAnimeDTO, bool, error) {
 	sqlStatement, stmtErr := adao.Db.Prepare(sqlStr)
 	if stmtErr != nil {
-		return nil, errors.WithStack(stmtErr)
+		return nil, false, errors.WithStack(stmtErr)
 	}
 	defer sqlStatement.Close()
-	result, resErr := sqlStatement.Query(internalUserID, fmt.Sprintf("%%%s%%", sentence), pageSize)
+	result, resErr := sqlStatement.Query(append(args, pageSize+1, offset)...)
 	if resErr != nil {
-		return nil, errors.WithStack(resErr)
+		return nil, false, errors.WithStack(resErr)
 	}
 	defer result.Close()
-	userAnimes := make([]UserAnimeDTO, 0, pageSize)
+	userAnimes := make([]UserAnimeDTO, 0, pageSize+1)
 	for result.Next() {
 		userAnimeDTO, scanErr := adao.scanAsUserAnime(result)
 		if scanErr != nil {
-			return nil, scanErr
+			return nil, false, scanErr
 		}
 		userAnimes = append(userAnimes, *userAnimeDTO)
 	}
-	return userAnimes, nil
+	if len(userAnimes) > pageSize {
+		return userAnimes[:pageSize], true, nil
+	}
+	return userAnimes, false, nil
 }
 
 //UserDAO struct
@@ -142,35 +379,20 @@ type UserDTO struct {
 	ID               int64
 	ExternalID       string
 	TelegramUsername string
+	Active           bool
+	Locale           string
+	Timezone         string
 }
 
-//Find func
-func (udao *UserDAO) Find(telegramID string) (*UserDTO, error) {
-	sqlStatement, stmtErr := udao.Db.Prepare(findUserByExternalIDSQL)
-	if stmtErr != nil {
-		return nil, errors.WithStack(stmtErr)
-	}
-	defer sqlStatement.Close()
-	result, resErr := sqlStatement.Query(telegramID)
-	if resErr != nil {
-		return nil, errors.WithStack(resErr)
-	}
-	defer result.Close()
-	if result.Next() {
-		userDTO, scanErr := udao.scanAsUser(result)
-		if scanErr != nil {
-			return nil, scanErr
-		}
-		return userDTO, nil
-	}
-	return nil, nil
-}
-
-func (udao *UserDAO) scanAsUser(result *sql.Rows) (*UserDTO, error) {
+//scanAsUser func scans user columns followed by optional extra columns into extra
+func scanAsUser(result *sql.Rows, extra ...interface{}) (*UserDTO, error) {
 	var id sql.NullInt64
 	var telegramID sql.NullString
 	var telegramUsername sql.NullString
-	scanErr := result.Scan(&id, &telegramID, &telegramUsername)
+	var active sql.NullBool
+	var locale sql.NullString
+	var timezone sql.NullString
+	scanErr := result.Scan(append([]interface{}{&id, &telegramID, &telegramUsername, &active, &locale, &timezone}, extra...)...)
 	if scanErr != nil {
 		return nil, errors.WithStack(scanErr)
 	}
@@ -184,53 +406,129 @@ func (udao *UserDAO) scanAsUser(result *sql.Rows) (*UserDTO, error) {
 	if telegramUsername.Valid {
 		userDTO.TelegramUsername = telegramUsername.String
 	}
+	if active.Valid {
+		userDTO.Active = active.Bool
+	}
+	if locale.Valid {
+		userDTO.Locale = locale.String
+	}
+	if timezone.Valid {
+		userDTO.Timezone = timezone.String
+	}
 	return &userDTO, nil
 }
 
-//Insert func
-func (udao *UserDAO) Insert(externalID string, username string) (*UserDTO, error) {
+//Upsert func inserts the user or returns existing one, inserted is true when the row was created.
+//Empty locale of existing user is replaced with given locale
+func (udao *UserDAO) Upsert(externalID string, username string, locale string) (userDTO *UserDTO, inserted bool, err error) {
+	sqlStatement, stmtErr := udao.Db.Prepare(upsertUserSQL)
+	if stmtErr != nil {
+		return nil, false, errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	result, resErr := sqlStatement.Query(externalID, username, locale)
+	if resErr != nil {
+		return nil, false, errors.WithStack(resErr)
+	}
+	defer result.Close()
+	if !result.Next() {
+		if rowsErr := result.Err(); rowsErr != nil {
+			return nil, false, errors.WithStack(rowsErr)
+		}
+		return nil, false, errors.Errorf("Upsert of user %s returned no row", externalID)
+	}
+	userDTO, scanErr := scanAsUser(result, &inserted)
+	if scanErr != nil {
+		return nil, false, scanErr
+	}
+	return userDTO, inserted, nil
+}
+
+//SetActive func
+func (udao *UserDAO) SetActive(userID int64, active bool) error {
+	sqlStatement, stmtErr := udao.Db.Prepare(updateUserActiveSQL)
+	if stmtErr != nil {
+		return errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	if _, resErr := sqlStatement.Exec(userID, active); resErr != nil {
+		return errors.WithStack(resErr)
+	}
+	return nil
+}
+
+//SetBlocked func marks the user who blocked or unblocked the bot, subscriptions of blocked user are kept
+func (udao *UserDAO) SetBlocked(telegramID string, blocked bool) error {
+	sqlStatement, stmtErr := udao.Db.Prepare(updateUserBlockedSQL)
+	if stmtErr != nil {
+		return errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	if _, resErr := sqlStatement.Exec(telegramID, blocked); resErr != nil {
+		return errors.WithStack(resErr)
+	}
+	return nil
+}
+
+//SetLocale func
+func (udao *UserDAO) SetLocale(userID int64, locale string) error {
+	sqlStatement, stmtErr := udao.Db.Prepare(updateUserLocaleSQL)
+	if stmtErr != nil {
+		return errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	if _, resErr := sqlStatement.Exec(userID, locale); resErr != nil {
+		return errors.WithStack(resErr)
+	}
+	return nil
+}
+
+//SetTimezone func
+func (udao *UserDAO) SetTimezone(userID int64, timezone string) error {
+	sqlStatement, stmtErr := udao.Db.Prepare(updateUserTimezoneSQL)
+	if stmtErr != nil {
+		return errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	if _, resErr := sqlStatement.Exec(userID, timezone); resErr != nil {
+		return errors.WithStack(resErr)
+	}
+	return nil
+}
+
+//Deactivate func removes every subscription of the user and marks the user inactive in one transaction,
+//message is written to outbox in the same transaction
+func (udao *UserDAO) Deactivate(userID int64, message *OutboxMessage) error {
 	tx, txErr := udao.Db.Begin()
 	if txErr != nil {
-		return nil, errors.WithStack(txErr)
+		return errors.WithStack(txErr)
 	}
-	userDTO, insertErr := udao.insert(tx, externalID, username)
-	if insertErr != nil {
+	if deactivateErr := udao.deactivate(tx, userID, message); deactivateErr != nil {
 		if rollbackErr := tx.Rollback(); rollbackErr != nil {
-			return nil, errors.WithStack(rollbackErr)
+			return errors.WithStack(rollbackErr)
 		}
-		return nil, insertErr
+		return deactivateErr
 	}
 	if commitErr := tx.Commit(); commitErr != nil {
-		return nil, errors.WithStack(commitErr)
+		return errors.WithStack(commitErr)
 	}
-	return userDTO, nil
+	return nil
 }
 
-func (udao *UserDAO) insert(tx *sql.Tx, externalID string, username string) (*UserDTO, error) {
-	sqlStatement, stmtErr := tx.Prepare(insertUserSQL)
+func (udao *UserDAO) deactivate(tx *sql.Tx, userID int64, message *OutboxMessage) error {
+	sdao := SubscriptionDAO{Db: udao.Db}
+	if err := sdao.deleteAll(tx, userID); err != nil {
+		return err
+	}
+	sqlStatement, stmtErr := tx.Prepare(updateUserActiveSQL)
 	if stmtErr != nil {
-		return nil, errors.WithStack(stmtErr)
+		return errors.WithStack(stmtErr)
 	}
 	defer sqlStatement.Close()
-	result, resErr := sqlStatement.Query(externalID, username)
-	if resErr != nil {
-		return nil, errors.WithStack(resErr)
-	}
-	defer result.Close()
-	userDTO := UserDTO{
-		ExternalID:       externalID,
-		TelegramUsername: username,
-	}
-	if result.Next() {
-		var ID sql.NullInt64
-		if err := result.Scan(&ID); err != nil {
-			return nil, errors.WithStack(err)
-		}
-		if ID.Valid {
-			userDTO.ID = ID.Int64
-		}
+	if _, resErr := sqlStatement.Exec(userID, false); resErr != nil {
+		return errors.WithStack(resErr)
 	}
-	return &userDTO, nil
+	return insertOutbox(tx, message)
 }
 
 //SubscriptionDAO struct
@@ -259,13 +557,40 @@ func (sdao *SubscriptionDAO) Find(userID int64, animeID int64) (bool, error) {
 	return result.Next(), nil
 }
 
-//Insert func
-func (sdao *SubscriptionDAO) Insert(userID int64, animeID int64) error {
+//ReadUserSubscriptions func returns page of animes the user is subscribed to, pages are numbered from 0.
+//Second result reports whether the next page exists
+func (sdao *SubscriptionDAO) ReadUserSubscriptions(userID int64, page int64) ([]AnimeDTO, bool, error) {
+	sqlStatement, stmtErr := sdao.Db.Prepare(findSubscribedAnimesByInternalUserIDSQL)
+	if stmtErr != nil {
+		return nil, false, errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	result, resErr := sqlStatement.Query(userID, subscriptionsPageSize+1, page*subscriptionsPageSize)
+	if resErr != nil {
+		return nil, false, errors.WithStack(resErr)
+	}
+	defer result.Close()
+	animes := make([]AnimeDTO, 0, subscriptionsPageSize+1)
+	for result.Next() {
+		animeDTO, scanErr := scanAsAnime(result)
+		if scanErr != nil {
+			return nil, false, scanErr
+		}
+		animes = append(animes, *animeDTO)
+	}
+	if len(animes) > subscriptionsPageSize {
+		return animes[:subscriptionsPageSize], true, nil
+	}
+	return animes, false, nil
+}
+
+//Insert func, message is written to outbox in the same transaction
+func (sdao *SubscriptionDAO) Insert(userID int64, animeID int64, message *OutboxMessage) error {
 	tx, txErr := sdao.Db.Begin()
 	if txErr != nil {
 		return errors.WithStack(txErr)
 	}
-	if insertErr := sdao.insert(tx, userID, animeID); insertErr != nil {
+	if insertErr := sdao.insert(tx, userID, animeID, message); insertErr != nil {
 		if rollbackErr := tx.Rollback(); rollbackErr != nil {
 			return errors.WithStack(rollbackErr)
 		}
@@ -277,7 +602,7 @@ func (sdao *SubscriptionDAO) Insert(userID int64, animeID int64) error {
 	return nil
 }
 
-func (sdao *SubscriptionDAO) insert(tx *sql.Tx, userID int64, animeID int64) error {
+func (sdao *SubscriptionDAO) insert(tx *sql.Tx, userID int64, animeID int64, message *OutboxMessage) error {
 	sqlStatement, stmtErr := tx.Prepare(insertSubscriptionSQL)
 	if stmtErr != nil {
 		return errors.WithStack(stmtErr)
@@ -287,16 +612,16 @@ func (sdao *SubscriptionDAO) insert(tx *sql.Tx, userID int64, animeID int64) err
 	if resErr != nil {
 		return errors.WithStack(resErr)
 	}
-	return nil
+	return insertOutbox(tx, message)
 }
 
-//Delete func
-func (sdao *SubscriptionDAO) Delete(userID int64, animeID int64) error {
+//Delete func, message is written to outbox in the same transaction
+func (sdao *SubscriptionDAO) Delete(userID int64, animeID int64, message *OutboxMessage) error {
 	tx, txErr := sdao.Db.Begin()
 	if txErr != nil {
 		return errors.WithStack(txErr)
 	}
-	if insertErr := sdao.delete(tx, userID, animeID); insertErr != nil {
+	if insertErr := sdao.delete(tx, userID, animeID, message); insertErr != nil {
 		if rollbackErr := tx.Rollback(); rollbackErr != nil {
 			return errors.WithStack(rollbackErr)
 		}
@@ -308,7 +633,7 @@ func (sdao *SubscriptionDAO) Delete(userID int64, animeID int64) error {
 	return nil
 }
 
-func (sdao *SubscriptionDAO) delete(tx *sql.Tx, userID int64, animeID int64) error {
+func (sdao *SubscriptionDAO) delete(tx *sql.Tx, userID int64, animeID int64, message *OutboxMessage) error {
 	sqlStatement, stmtErr := tx.Prepare(deleteSubscriptionSQL)
 	if stmtErr != nil {
 		return errors.WithStack(stmtErr)
@@ -318,6 +643,40 @@ func (sdao *SubscriptionDAO) delete(tx *sql.Tx, userID int64, animeID int64) err
 	if resErr != nil {
 		return errors.WithStack(resErr)
 	}
+	return insertOutbox(tx, message)
+}
+
+//DeleteAll func removes every subscription of the user, message is written to outbox in the same transaction
+func (sdao *SubscriptionDAO) DeleteAll(userID int64, message *OutboxMessage) error {
+	tx, txErr := sdao.Db.Begin()
+	if txErr != nil {
+		return errors.WithStack(txErr)
+	}
+	deleteErr := sdao.deleteAll(tx, userID)
+	if deleteErr == nil {
+		deleteErr = insertOutbox(tx, message)
+	}
+	if deleteErr != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return errors.WithStack(rollbackErr)
+		}
+		return deleteErr
+	}
+	if commitErr := tx.Commit(); commitErr != nil {
+		return errors.WithStack(commitErr)
+	}
+	return nil
+}
+
+func (sdao *SubscriptionDAO) deleteAll(tx *sql.Tx, userID int64) error {
+	sqlStatement, stmtErr := tx.Prepare(deleteAllSubscriptionsSQL)
+	if stmtErr != nil {
+		return errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	if _, resErr := sqlStatement.Exec(userID); resErr != nil {
+		return errors.WithStack(resErr)
+	}
 	return nil
 }
 
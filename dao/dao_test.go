@@ -0,0 +1,213 @@
+package dao
+
+import (
+	sql "database/sql"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+//testDatabaseURLEnvName points tests to Postgres database, tests which need it are skipped when it is not set
+const testDatabaseURLEnvName = "TEST_DATABASE_URL"
+
+func openTestDB(t *testing.T) *sql.DB {
+	databaseURL := os.Getenv(testDatabaseURLEnvName)
+	if databaseURL == "" {
+		t.Skip(testDatabaseURLEnvName + " is not set")
+	}
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, migrateErr := migrate.Exec(db, "postgres", &migrate.FileMigrationSource{Dir: "../migrations"}, migrate.Up); migrateErr != nil {
+		t.Fatal(migrateErr)
+	}
+	return db
+}
+
+func TestUpsertCreatesOneUserForConcurrentCalls(t *testing.T) {
+	db := openTestDB(t)
+	udao := &UserDAO{Db: db}
+	externalID := strconv.FormatInt(time.Now().UnixNano(), 10)
+	t.Cleanup(func() {
+		if _, err := db.Exec("DELETE FROM TELEGRAM_USERS WHERE TELEGRAM_USER_ID = $1", externalID); err != nil {
+			t.Error(err)
+		}
+	})
+	const callers = 16
+	users := make([]*UserDTO, callers)
+	inserted := make([]bool, callers)
+	errs := make([]error, callers)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			users[i], inserted[i], errs[i] = udao.Upsert(externalID, "username", "en")
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	insertedCount := 0
+	for i := 0; i < callers; i++ {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		if users[i] == nil {
+			t.Fatalf("call %d returned no user", i)
+		}
+		if users[i].ID != users[0].ID {
+			t.Fatalf("call %d returned user %d, call 0 returned user %d", i, users[i].ID, users[0].ID)
+		}
+		if inserted[i] {
+			insertedCount++
+		}
+	}
+	if insertedCount != 1 {
+		t.Fatalf("%d calls inserted the user, want 1", insertedCount)
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM TELEGRAM_USERS WHERE TELEGRAM_USER_ID = $1", externalID).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("%d rows of the user, want 1", count)
+	}
+}
+
+func TestUpsertKeepsReleasedEpisodeUntilNotified(t *testing.T) {
+	db := openTestDB(t)
+	adao := &AnimeDAO{Db: db}
+	externalID := "test-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	t.Cleanup(func() {
+		if _, err := db.Exec("DELETE FROM ANIMES WHERE EXTERNALID = $1", externalID); err != nil {
+			t.Error(err)
+		}
+	})
+	releasedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	nextEpisodeAt := time.Now().Add(7 * 24 * time.Hour).Truncate(time.Second)
+	readSchedule := func() (time.Time, bool) {
+		var scheduledAt time.Time
+		var notificationSent bool
+		if err := db.QueryRow("SELECT NEXT_EPISODE_AT, NOTIFICATION_SENT FROM ANIMES WHERE EXTERNALID = $1", externalID).Scan(&scheduledAt, &notificationSent); err != nil {
+			t.Fatal(err)
+		}
+		return scheduledAt, notificationSent
+	}
+	if err := adao.Upsert(&AnimeDTO{ExternalID: externalID, RusName: "Аниме", EngName: "Anime", NextEpisodeAt: releasedAt}); err != nil {
+		t.Fatal(err)
+	}
+	if err := adao.Upsert(&AnimeDTO{ExternalID: externalID, RusName: "Аниме", EngName: "Anime", NextEpisodeAt: nextEpisodeAt}); err != nil {
+		t.Fatal(err)
+	}
+	if scheduledAt, notificationSent := readSchedule(); !scheduledAt.Equal(releasedAt) || notificationSent {
+		t.Fatalf("import before notification moved episode to %v, notification sent %t", scheduledAt, notificationSent)
+	}
+	if _, err := adao.NotifyReleased(func(anime *AnimeDTO, subscriber *SubscriberDTO) (*Notification, error) { return nil, nil }); err != nil {
+		t.Fatal(err)
+	}
+	if _, notificationSent := readSchedule(); !notificationSent {
+		t.Fatal("released episode is not notified")
+	}
+	if err := adao.Upsert(&AnimeDTO{ExternalID: externalID, RusName: "Аниме", EngName: "Anime", NextEpisodeAt: nextEpisodeAt}); err != nil {
+		t.Fatal(err)
+	}
+	if scheduledAt, notificationSent := readSchedule(); !scheduledAt.Equal(nextEpisodeAt) || notificationSent {
+		t.Fatalf("import after notification scheduled episode at %v, notification sent %t", scheduledAt, notificationSent)
+	}
+}
+
+func TestRateLimitIsSharedByReplicas(t *testing.T) {
+	db := openTestDB(t)
+	name := "test" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	t.Cleanup(func() {
+		if _, err := db.Exec("DELETE FROM RATE_LIMITS WHERE NAME = $1", name); err != nil {
+			t.Error(err)
+		}
+	})
+	replicas := []*RateLimitDAO{{Db: db}, {Db: db}}
+	const rate = 5
+	for i := 0; i < rate; i++ {
+		wait, err := replicas[i%len(replicas)].Reserve(name, rate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wait != 0 {
+			t.Fatalf("reservation %d waits %v within burst", i, wait)
+		}
+	}
+	wait, err := replicas[1].Reserve(name, rate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wait <= 0 || wait > time.Second/rate {
+		t.Fatalf("reservation over burst waits %v, expected up to %v", wait, time.Second/rate)
+	}
+	if err := replicas[1].Cancel(name); err != nil {
+		t.Fatal(err)
+	}
+	if err := replicas[0].Block(name, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	wait, err = replicas[1].Reserve(name, rate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wait < 59*time.Second {
+		t.Fatalf("reservation of blocked limit waits %v, expected about a minute", wait)
+	}
+}
+
+func TestSearchUserAnimesRanksExactThenPrefixThenRest(t *testing.T) {
+	db := openTestDB(t)
+	adao := &AnimeDAO{Db: db}
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 10)
+	term := "zqx" + suffix
+	animes := []*AnimeDTO{
+		{ExternalID: "test-rest-" + suffix, RusName: "Аниме", EngName: "The " + term + " movie"},
+		{ExternalID: "test-prefix-" + suffix, RusName: "Аниме", EngName: term + " season 2"},
+		{ExternalID: "test-exact-" + suffix, RusName: "Аниме", EngName: term},
+	}
+	t.Cleanup(func() {
+		for _, anime := range animes {
+			if _, err := db.Exec("DELETE FROM ANIMES WHERE EXTERNALID = $1", anime.ExternalID); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+	for _, anime := range animes {
+		anime.NextEpisodeAt = time.Now().Add(24 * time.Hour)
+		if err := adao.Upsert(anime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	found, _, err := adao.SearchUserAnimes(0, "  "+term+" ", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ranked []string
+	for _, anime := range found {
+		for _, inserted := range animes {
+			if anime.ExternalID == inserted.ExternalID {
+				ranked = append(ranked, anime.ExternalID)
+			}
+		}
+	}
+	expected := []string{animes[2].ExternalID, animes[1].ExternalID, animes[0].ExternalID}
+	if len(ranked) != len(expected) {
+		t.Fatalf("search found %v, expected %v", ranked, expected)
+	}
+	for i := range expected {
+		if ranked[i] != expected[i] {
+			t.Fatalf("search ranked %v, expected %v", ranked, expected)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package dao
+
+import (
+	sql "database/sql"
+
+	"github.com/pkg/errors"
+)
+
+const insertDeadLetterSQL = "INSERT INTO DEAD_LETTERS (CHAT_ID, CONTENT_TYPE, MESSAGE, REASON) VALUES($1, $2, $3, $4)"
+
+//insertDeadLetter func stores outbox message which will never be delivered
+func insertDeadLetter(tx *sql.Tx, message *OutboxMessage, reason string) error {
+	sqlStatement, stmtErr := tx.Prepare(insertDeadLetterSQL)
+	if stmtErr != nil {
+		return errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	if _, resErr := sqlStatement.Exec(nullableChatID(message.ChatID), message.ContentType, message.Data, reason); resErr != nil {
+		return errors.WithStack(resErr)
+	}
+	return nil
+}
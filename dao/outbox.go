@@ -0,0 +1,225 @@
+package dao
+
+import (
+	sql "database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//maxOutboxBackoff limits delay between publish attempts of one message
+const maxOutboxBackoff = 5 * time.Minute
+
+//Outcomes of relaying one outbox message
+const (
+	outboxEmpty = iota
+	outboxDelivered
+	//outboxSkipped message is postponed or dead-lettered, relay goes on with the next message
+	outboxSkipped
+	outboxFailed
+)
+
+//OutboxDAO struct
+type OutboxDAO struct {
+	Db *sql.DB
+}
+
+//OutboxMessage struct, zero ChatID is stored as NULL for messages without chat like inline query answers.
+//CreatedAt is set for messages read from outbox. MessageID stays the same for every publish attempt, empty MessageID is stored as NULL
+type OutboxMessage struct {
+	ChatID      int64
+	ContentType string
+	Data        []byte
+	MessageID   string
+	CreatedAt   time.Time
+}
+
+//PostponeError is returned by publish when the message can not be sent before RetryAt, e.g. its chat is rate limited.
+//The message is not counted as failed attempt and relay goes on with the next message
+type PostponeError struct {
+	RetryAt time.Time
+	Err     error
+}
+
+func (pe *PostponeError) Error() string {
+	return pe.Err.Error()
+}
+
+//Unwrap func
+func (pe *PostponeError) Unwrap() error {
+	return pe.Err
+}
+
+//DeadLetterError is returned by publish when the message will never be delivered,
+//the message is moved to DEAD_LETTERS with Reason and relay goes on with the next message
+type DeadLetterError struct {
+	Reason string
+	Err    error
+}
+
+func (de *DeadLetterError) Error() string {
+	return de.Err.Error()
+}
+
+//Unwrap func
+func (de *DeadLetterError) Unwrap() error {
+	return de.Err
+}
+
+const (
+	insertOutboxSQL      = "INSERT INTO OUTBOX (CHAT_ID, MESSAGE, CONTENT_TYPE, MESSAGE_ID) VALUES($1, $2, $3, $4)"
+	findPendingOutboxSQL = "SELECT ID, CHAT_ID, MESSAGE, CONTENT_TYPE, MESSAGE_ID, CREATED_AT, ATTEMPTS FROM OUTBOX WHERE DELIVERED_AT IS NULL AND NEXT_ATTEMPT_AT <= NOW()" +
+		" ORDER BY ID LIMIT 1 FOR UPDATE SKIP LOCKED"
+	markOutboxDeliveredSQL = "UPDATE OUTBOX SET DELIVERED_AT = NOW(), ATTEMPTS = ATTEMPTS + 1 WHERE ID = $1"
+	markOutboxFailedSQL    = "UPDATE OUTBOX SET ATTEMPTS = ATTEMPTS + 1, NEXT_ATTEMPT_AT = NOW() + $2 * INTERVAL '1 millisecond' WHERE ID = $1"
+	postponeOutboxSQL      = "UPDATE OUTBOX SET NEXT_ATTEMPT_AT = $2 WHERE ID = $1"
+	deleteOutboxSQL        = "DELETE FROM OUTBOX WHERE ID = $1"
+	deleteDeliveredSQL     = "DELETE FROM OUTBOX WHERE DELIVERED_AT < $1"
+)
+
+//Enqueue func writes message to outbox, it is delivered by the next Relay call
+func (odao *OutboxDAO) Enqueue(message *OutboxMessage) error {
+	sqlStatement, stmtErr := odao.Db.Prepare(insertOutboxSQL)
+	if stmtErr != nil {
+		return errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	if _, resErr := sqlStatement.Exec(nullableChatID(message.ChatID), message.Data, message.ContentType, nullableMessageID(message.MessageID)); resErr != nil {
+		return errors.WithStack(resErr)
+	}
+	return nil
+}
+
+//Relay func calls publish with every pending outbox message in order of insertion and marks it delivered,
+//returns count of delivered messages. Postponed and dead-lettered messages are skipped, any other failed message
+//is postponed with exponential backoff and relay stops until the next call
+func (odao *OutboxDAO) Relay(publish func(message *OutboxMessage) error, backoff time.Duration) (int, error) {
+	count := 0
+	for {
+		tx, txErr := odao.Db.Begin()
+		if txErr != nil {
+			return count, errors.WithStack(txErr)
+		}
+		outcome, publishErr, relayErr := odao.relay(tx, publish, backoff)
+		if relayErr != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return count, errors.WithStack(rollbackErr)
+			}
+			return count, relayErr
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			return count, errors.WithStack(commitErr)
+		}
+		switch outcome {
+		case outboxEmpty:
+			return count, nil
+		case outboxFailed:
+			return count, publishErr
+		case outboxDelivered:
+			count++
+		}
+	}
+}
+
+//relay func returns publish error separately, because postponement of the failed message has to be committed
+func (odao *OutboxDAO) relay(tx *sql.Tx, publish func(message *OutboxMessage) error, backoff time.Duration) (int, error, error) {
+	id, message, attempts, findErr := odao.findPending(tx)
+	if findErr != nil || message == nil {
+		return outboxEmpty, nil, findErr
+	}
+	publishErr := publish(message)
+	if publishErr == nil {
+		return outboxDelivered, nil, odao.exec(tx, markOutboxDeliveredSQL, id)
+	}
+	var postponeErr *PostponeError
+	if errors.As(publishErr, &postponeErr) {
+		return outboxSkipped, nil, odao.exec(tx, postponeOutboxSQL, id, postponeErr.RetryAt)
+	}
+	var deadLetterErr *DeadLetterError
+	if errors.As(publishErr, &deadLetterErr) {
+		if insertErr := insertDeadLetter(tx, message, deadLetterErr.Reason); insertErr != nil {
+			return outboxEmpty, nil, insertErr
+		}
+		return outboxSkipped, nil, odao.exec(tx, deleteOutboxSQL, id)
+	}
+	delay := backoff << uint(attempts)
+	if delay > maxOutboxBackoff || delay <= 0 {
+		delay = maxOutboxBackoff
+	}
+	if markErr := odao.exec(tx, markOutboxFailedSQL, id, delay.Nanoseconds()/int64(time.Millisecond)); markErr != nil {
+		return outboxEmpty, nil, markErr
+	}
+	return outboxFailed, publishErr, nil
+}
+
+func (odao *OutboxDAO) findPending(tx *sql.Tx) (int64, *OutboxMessage, int, error) {
+	sqlStatement, stmtErr := tx.Prepare(findPendingOutboxSQL)
+	if stmtErr != nil {
+		return 0, nil, 0, errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	result, resErr := sqlStatement.Query()
+	if resErr != nil {
+		return 0, nil, 0, errors.WithStack(resErr)
+	}
+	defer result.Close()
+	if !result.Next() {
+		return 0, nil, 0, nil
+	}
+	var id int64
+	var chatID sql.NullInt64
+	var messageID sql.NullString
+	var attempts int
+	message := &OutboxMessage{}
+	if scanErr := result.Scan(&id, &chatID, &message.Data, &message.ContentType, &messageID, &message.CreatedAt, &attempts); scanErr != nil {
+		return 0, nil, 0, errors.WithStack(scanErr)
+	}
+	message.ChatID = chatID.Int64
+	message.MessageID = messageID.String
+	return id, message, attempts, nil
+}
+
+//DeleteDelivered func removes messages delivered before the given time
+func (odao *OutboxDAO) DeleteDelivered(before time.Time) error {
+	sqlStatement, stmtErr := odao.Db.Prepare(deleteDeliveredSQL)
+	if stmtErr != nil {
+		return errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	if _, resErr := sqlStatement.Exec(before); resErr != nil {
+		return errors.WithStack(resErr)
+	}
+	return nil
+}
+
+func (odao *OutboxDAO) exec(tx *sql.Tx, sqlStr string, args ...interface{}) error {
+	sqlStatement, stmtErr := tx.Prepare(sqlStr)
+	if stmtErr != nil {
+		return errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	if _, resErr := sqlStatement.Exec(args...); resErr != nil {
+		return errors.WithStack(resErr)
+	}
+	return nil
+}
+
+func insertOutbox(tx *sql.Tx, message *OutboxMessage) error {
+	sqlStatement, stmtErr := tx.Prepare(insertOutboxSQL)
+	if stmtErr != nil {
+		return errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	if _, resErr := sqlStatement.Exec(nullableChatID(message.ChatID), message.Data, message.ContentType, nullableMessageID(message.MessageID)); resErr != nil {
+		return errors.WithStack(resErr)
+	}
+	return nil
+}
+
+func nullableChatID(chatID int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: chatID, Valid: chatID != 0}
+}
+
+func nullableMessageID(messageID string) sql.NullString {
+	return sql.NullString{String: messageID, Valid: messageID != ""}
+}
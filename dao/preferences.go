@@ -0,0 +1,233 @@
+package dao
+
+import (
+	sql "database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//Notification modes of USER_PREFERENCES
+const (
+	InstantMode = "instant"
+	DailyMode   = "daily"
+	WeeklyMode  = "weekly"
+)
+
+//PreferencesDAO struct
+type PreferencesDAO struct {
+	Db *sql.DB
+}
+
+//PreferencesDTO struct
+type PreferencesDTO struct {
+	UserID           int64
+	NotificationMode string
+	DigestHour       int
+	LastDigestAt     time.Time
+	//quiet hours are disabled when QuietFrom equals QuietTo
+	QuietFrom int
+	QuietTo   int
+}
+
+//DigestUserDTO struct
+type DigestUserDTO struct {
+	UserDTO
+	Preferences PreferencesDTO
+}
+
+const (
+	findPreferencesSQL = "SELECT TELEGRAM_USER_ID, NOTIFICATION_MODE, DIGEST_HOUR, LAST_DIGEST_AT, QUIET_FROM, QUIET_TO FROM USER_PREFERENCES WHERE TELEGRAM_USER_ID = $1"
+	upsertModeSQL      = "INSERT INTO USER_PREFERENCES (TELEGRAM_USER_ID, NOTIFICATION_MODE, DIGEST_HOUR, LAST_DIGEST_AT) VALUES($1, $2, $3, NOW())" +
+		" ON CONFLICT (TELEGRAM_USER_ID) DO UPDATE SET NOTIFICATION_MODE = EXCLUDED.NOTIFICATION_MODE, DIGEST_HOUR = EXCLUDED.DIGEST_HOUR," +
+		" LAST_DIGEST_AT = CASE WHEN USER_PREFERENCES.NOTIFICATION_MODE = $4 THEN NOW() ELSE USER_PREFERENCES.LAST_DIGEST_AT END"
+	findDigestUsersSQL = "SELECT TU.ID, TU.TELEGRAM_USER_ID, TU.TELEGRAM_USERNAME, TU.ACTIVE, TU.LOCALE, TU.TIMEZONE," +
+		" UP.NOTIFICATION_MODE, UP.DIGEST_HOUR, UP.LAST_DIGEST_AT FROM USER_PREFERENCES AS UP" +
+		" JOIN TELEGRAM_USERS AS TU ON (UP.TELEGRAM_USER_ID = TU.ID) WHERE UP.NOTIFICATION_MODE <> $1 AND TU.ACTIVE = TRUE AND TU.BLOCKED = FALSE"
+	lockLastDigestSQL = "SELECT LAST_DIGEST_AT FROM USER_PREFERENCES WHERE TELEGRAM_USER_ID = $1 FOR UPDATE"
+	//findReleasedSinceSQL puts RELEASED_AT of the episode in place of NEXT_EPISODE_AT
+	findReleasedSinceSQL = "SELECT ANS.ID, ANS.EXTERNALID, ANS.RUSNAME, ANS.ENGNAME, ANS.IMAGEURL, ER.RELEASED_AT, ANS.NOTIFICATION_SENT FROM EPISODE_RELEASES AS ER" +
+		" JOIN SUBSCRIPTIONS AS SS ON (ER.ANIME_ID = SS.ANIME_ID AND SS.TELEGRAM_USER_ID = $1) JOIN ANIMES AS ANS ON (ER.ANIME_ID = ANS.ID)" +
+		" WHERE ER.RELEASED_AT > $2 ORDER BY ER.RELEASED_AT, ANS.ID"
+	updateLastDigestSQL     = "UPDATE USER_PREFERENCES SET LAST_DIGEST_AT = NOW() WHERE TELEGRAM_USER_ID = $1"
+	insertEpisodeReleaseSQL = "INSERT INTO EPISODE_RELEASES (ANIME_ID, RELEASED_AT) VALUES($1, $2) ON CONFLICT DO NOTHING"
+)
+
+//Find func returns preferences of the user, users without stored preferences get instant mode
+func (pdao *PreferencesDAO) Find(userID int64) (*PreferencesDTO, error) {
+	sqlStatement, stmtErr := pdao.Db.Prepare(findPreferencesSQL)
+	if stmtErr != nil {
+		return nil, errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	result, resErr := sqlStatement.Query(userID)
+	if resErr != nil {
+		return nil, errors.WithStack(resErr)
+	}
+	defer result.Close()
+	if !result.Next() {
+		return &PreferencesDTO{UserID: userID, NotificationMode: InstantMode}, nil
+	}
+	preferencesDTO := PreferencesDTO{}
+	var lastDigestAt PqTime
+	if scanErr := result.Scan(&preferencesDTO.UserID, &preferencesDTO.NotificationMode, &preferencesDTO.DigestHour, &lastDigestAt,
+		&preferencesDTO.QuietFrom, &preferencesDTO.QuietTo); scanErr != nil {
+		return nil, errors.WithStack(scanErr)
+	}
+	if lastDigestAt.Valid {
+		preferencesDTO.LastDigestAt = lastDigestAt.Time
+	}
+	return &preferencesDTO, nil
+}
+
+//SetNotificationMode func. Switching from instant mode starts collecting digest from now
+func (pdao *PreferencesDAO) SetNotificationMode(userID int64, mode string, digestHour int) error {
+	sqlStatement, stmtErr := pdao.Db.Prepare(upsertModeSQL)
+	if stmtErr != nil {
+		return errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	if _, resErr := sqlStatement.Exec(userID, mode, digestHour, InstantMode); resErr != nil {
+		return errors.WithStack(resErr)
+	}
+	return nil
+}
+
+//ReadDigestUsers func returns active users with daily or weekly notification mode
+func (pdao *PreferencesDAO) ReadDigestUsers() ([]DigestUserDTO, error) {
+	sqlStatement, stmtErr := pdao.Db.Prepare(findDigestUsersSQL)
+	if stmtErr != nil {
+		return nil, errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	result, resErr := sqlStatement.Query(InstantMode)
+	if resErr != nil {
+		return nil, errors.WithStack(resErr)
+	}
+	defer result.Close()
+	users := make([]DigestUserDTO, 0)
+	for result.Next() {
+		var id sql.NullInt64
+		var telegramID, telegramUsername, locale, timezone sql.NullString
+		var active sql.NullBool
+		var lastDigestAt PqTime
+		digestUserDTO := DigestUserDTO{}
+		scanErr := result.Scan(&id, &telegramID, &telegramUsername, &active, &locale, &timezone,
+			&digestUserDTO.Preferences.NotificationMode, &digestUserDTO.Preferences.DigestHour, &lastDigestAt)
+		if scanErr != nil {
+			return nil, errors.WithStack(scanErr)
+		}
+		digestUserDTO.ID = id.Int64
+		digestUserDTO.ExternalID = telegramID.String
+		digestUserDTO.TelegramUsername = telegramUsername.String
+		digestUserDTO.Active = active.Bool
+		digestUserDTO.Locale = locale.String
+		digestUserDTO.Timezone = timezone.String
+		digestUserDTO.Preferences.UserID = id.Int64
+		if lastDigestAt.Valid {
+			digestUserDTO.Preferences.LastDigestAt = lastDigestAt.Time
+		}
+		users = append(users, digestUserDTO)
+	}
+	return users, nil
+}
+
+//BuildDigest func calls build with every episode of subscribed animes released since the last digest of the user,
+//NextEpisodeAt of each anime holds release time of the episode. Returned message is written to outbox and
+//LAST_DIGEST_AT is moved in the same transaction, so a failed build leaves the episodes for the next digest.
+//Nil message means nothing to deliver
+func (pdao *PreferencesDAO) BuildDigest(userID int64, build func(releases []AnimeDTO) (*OutboxMessage, error)) error {
+	tx, txErr := pdao.Db.Begin()
+	if txErr != nil {
+		return errors.WithStack(txErr)
+	}
+	if buildErr := pdao.buildDigest(tx, userID, build); buildErr != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return errors.WithStack(rollbackErr)
+		}
+		return buildErr
+	}
+	if commitErr := tx.Commit(); commitErr != nil {
+		return errors.WithStack(commitErr)
+	}
+	return nil
+}
+
+func (pdao *PreferencesDAO) buildDigest(tx *sql.Tx, userID int64, build func(releases []AnimeDTO) (*OutboxMessage, error)) error {
+	lastDigestAt, lockErr := pdao.lockLastDigest(tx, userID)
+	if lockErr != nil {
+		return lockErr
+	}
+	releases, releasesErr := pdao.findReleasedSince(tx, userID, lastDigestAt)
+	if releasesErr != nil {
+		return releasesErr
+	}
+	sqlStatement, stmtErr := tx.Prepare(updateLastDigestSQL)
+	if stmtErr != nil {
+		return errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	if _, resErr := sqlStatement.Exec(userID); resErr != nil {
+		return errors.WithStack(resErr)
+	}
+	message, buildErr := build(releases)
+	if buildErr != nil || message == nil {
+		return buildErr
+	}
+	return insertOutbox(tx, message)
+}
+
+func (pdao *PreferencesDAO) lockLastDigest(tx *sql.Tx, userID int64) (time.Time, error) {
+	sqlStatement, stmtErr := tx.Prepare(lockLastDigestSQL)
+	if stmtErr != nil {
+		return time.Time{}, errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	result, resErr := sqlStatement.Query(userID)
+	if resErr != nil {
+		return time.Time{}, errors.WithStack(resErr)
+	}
+	defer result.Close()
+	if !result.Next() {
+		return time.Time{}, errors.Errorf("Preferences of user %d not found", userID)
+	}
+	var lastDigestAt PqTime
+	if scanErr := result.Scan(&lastDigestAt); scanErr != nil {
+		return time.Time{}, errors.WithStack(scanErr)
+	}
+	return lastDigestAt.Time, nil
+}
+
+func (pdao *PreferencesDAO) findReleasedSince(tx *sql.Tx, userID int64, since time.Time) ([]AnimeDTO, error) {
+	sqlStatement, stmtErr := tx.Prepare(findReleasedSinceSQL)
+	if stmtErr != nil {
+		return nil, errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	result, resErr := sqlStatement.Query(userID, since)
+	if resErr != nil {
+		return nil, errors.WithStack(resErr)
+	}
+	defer result.Close()
+	releases := make([]AnimeDTO, 0)
+	for result.Next() {
+		animeDTO, scanErr := scanAsAnime(result)
+		if scanErr != nil {
+			return nil, scanErr
+		}
+		releases = append(releases, *animeDTO)
+	}
+	return releases, nil
+}
+
+func insertEpisodeRelease(tx *sql.Tx, animeID int64, releasedAt time.Time) error {
+	sqlStatement, stmtErr := tx.Prepare(insertEpisodeReleaseSQL)
+	if stmtErr != nil {
+		return errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	if _, resErr := sqlStatement.Exec(animeID, releasedAt); resErr != nil {
+		return errors.WithStack(resErr)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package dao
+
+import (
+	sql "database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//SubscriberDTO struct, Preferences holds quiet hours of the user
+type SubscriberDTO struct {
+	UserDTO
+	Preferences PreferencesDTO
+}
+
+//Notification struct is a message to the subscriber. Message with non-zero HoldUntil is kept
+//in HELD_NOTIFICATIONS and moved to outbox when HoldUntil has passed
+type Notification struct {
+	Message   *OutboxMessage
+	HoldUntil time.Time
+}
+
+const (
+	upsertQuietHoursSQL = "INSERT INTO USER_PREFERENCES (TELEGRAM_USER_ID, QUIET_FROM, QUIET_TO) VALUES($1, $2, $3)" +
+		" ON CONFLICT (TELEGRAM_USER_ID) DO UPDATE SET QUIET_FROM = EXCLUDED.QUIET_FROM, QUIET_TO = EXCLUDED.QUIET_TO"
+	insertHeldNotificationSQL = "INSERT INTO HELD_NOTIFICATIONS (TELEGRAM_USER_ID, RELEASE_AT, CHAT_ID, MESSAGE, CONTENT_TYPE, MESSAGE_ID) VALUES($1, $2, $3, $4, $5, $6)"
+	//releaseHeldNotificationsSQL drops messages of users who blocked the bot
+	releaseHeldNotificationsSQL = "WITH RELEASED AS (DELETE FROM HELD_NOTIFICATIONS WHERE RELEASE_AT <= NOW()" +
+		" RETURNING ID, TELEGRAM_USER_ID, CHAT_ID, MESSAGE, CONTENT_TYPE, MESSAGE_ID)" +
+		" INSERT INTO OUTBOX (CHAT_ID, MESSAGE, CONTENT_TYPE, MESSAGE_ID) SELECT RS.CHAT_ID, RS.MESSAGE, RS.CONTENT_TYPE, RS.MESSAGE_ID FROM RELEASED AS RS" +
+		" JOIN TELEGRAM_USERS AS TU ON (RS.TELEGRAM_USER_ID = TU.ID) WHERE TU.BLOCKED = FALSE ORDER BY RS.ID"
+)
+
+//SetQuietHours func, equal quietFrom and quietTo disable quiet hours
+func (pdao *PreferencesDAO) SetQuietHours(userID int64, quietFrom, quietTo int) error {
+	sqlStatement, stmtErr := pdao.Db.Prepare(upsertQuietHoursSQL)
+	if stmtErr != nil {
+		return errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	if _, resErr := sqlStatement.Exec(userID, quietFrom, quietTo); resErr != nil {
+		return errors.WithStack(resErr)
+	}
+	return nil
+}
+
+//ReleaseHeld func moves every held message whose RELEASE_AT has passed to outbox, returns count of released messages
+func (pdao *PreferencesDAO) ReleaseHeld() (int, error) {
+	sqlStatement, stmtErr := pdao.Db.Prepare(releaseHeldNotificationsSQL)
+	if stmtErr != nil {
+		return 0, errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	result, resErr := sqlStatement.Exec()
+	if resErr != nil {
+		return 0, errors.WithStack(resErr)
+	}
+	count, countErr := result.RowsAffected()
+	if countErr != nil {
+		return 0, errors.WithStack(countErr)
+	}
+	return int(count), nil
+}
+
+//insertNotification func writes message to outbox or holds it, nil notification is skipped
+func insertNotification(tx *sql.Tx, userID int64, notification *Notification) error {
+	if notification == nil {
+		return nil
+	}
+	if notification.HoldUntil.IsZero() {
+		return insertOutbox(tx, notification.Message)
+	}
+	sqlStatement, stmtErr := tx.Prepare(insertHeldNotificationSQL)
+	if stmtErr != nil {
+		return errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	message := notification.Message
+	if _, resErr := sqlStatement.Exec(userID, notification.HoldUntil, nullableChatID(message.ChatID), message.Data, message.ContentType, nullableMessageID(message.MessageID)); resErr != nil {
+		return errors.WithStack(resErr)
+	}
+	return nil
+}
+
+func scanAsSubscriber(result *sql.Rows) (*SubscriberDTO, error) {
+	var quietFrom, quietTo int
+	userDTO, scanErr := scanAsUser(result, &quietFrom, &quietTo)
+	if scanErr != nil {
+		return nil, scanErr
+	}
+	return &SubscriberDTO{
+		UserDTO:     *userDTO,
+		Preferences: PreferencesDTO{UserID: userDTO.ID, QuietFrom: quietFrom, QuietTo: quietTo},
+	}, nil
+}
@@ -0,0 +1,60 @@
+package dao
+
+import (
+	sql "database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//RateLimitDAO struct keeps token buckets in RATE_LIMITS table, so a limit is shared by all replicas.
+//Time is measured by database clock, so clocks of replicas do not affect the limit
+type RateLimitDAO struct {
+	Db *sql.DB
+}
+
+const (
+	reserveRateLimitSQL = "INSERT INTO RATE_LIMITS (NAME, TOKENS, UPDATED_AT, BLOCKED_UNTIL) VALUES($1, $2::DOUBLE PRECISION - 1, CLOCK_TIMESTAMP(), CLOCK_TIMESTAMP())" +
+		" ON CONFLICT (NAME) DO UPDATE SET UPDATED_AT = CLOCK_TIMESTAMP()," +
+		" TOKENS = LEAST($2::DOUBLE PRECISION, RATE_LIMITS.TOKENS + EXTRACT(EPOCH FROM CLOCK_TIMESTAMP() - RATE_LIMITS.UPDATED_AT)::DOUBLE PRECISION * $2::DOUBLE PRECISION) - 1" +
+		" RETURNING GREATEST(-TOKENS / $2::DOUBLE PRECISION, EXTRACT(EPOCH FROM BLOCKED_UNTIL - CLOCK_TIMESTAMP())::DOUBLE PRECISION, 0)"
+	cancelRateLimitSQL = "UPDATE RATE_LIMITS SET TOKENS = TOKENS + 1 WHERE NAME = $1"
+	blockRateLimitSQL  = "UPDATE RATE_LIMITS SET BLOCKED_UNTIL = GREATEST(BLOCKED_UNTIL, CLOCK_TIMESTAMP() + $2::DOUBLE PRECISION * INTERVAL '1 second') WHERE NAME = $1"
+)
+
+//Reserve func takes one token of the bucket which is refilled with rate tokens per second
+//and returns how long to wait until the token is available
+func (rldao *RateLimitDAO) Reserve(name string, rate int) (time.Duration, error) {
+	sqlStatement, stmtErr := rldao.Db.Prepare(reserveRateLimitSQL)
+	if stmtErr != nil {
+		return 0, errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	var wait float64
+	if scanErr := sqlStatement.QueryRow(name, rate).Scan(&wait); scanErr != nil {
+		return 0, errors.WithStack(scanErr)
+	}
+	return time.Duration(wait * float64(time.Second)), nil
+}
+
+//Cancel func returns token of reservation which will not be used
+func (rldao *RateLimitDAO) Cancel(name string) error {
+	return rldao.exec(cancelRateLimitSQL, name)
+}
+
+//Block func makes every reservation wait until duration passes
+func (rldao *RateLimitDAO) Block(name string, duration time.Duration) error {
+	return rldao.exec(blockRateLimitSQL, name, duration.Seconds())
+}
+
+func (rldao *RateLimitDAO) exec(sqlStr string, args ...interface{}) error {
+	sqlStatement, stmtErr := rldao.Db.Prepare(sqlStr)
+	if stmtErr != nil {
+		return errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	if _, resErr := sqlStatement.Exec(args...); resErr != nil {
+		return errors.WithStack(resErr)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package dao
+
+import (
+	sql "database/sql"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	updateReminderLeadSQL = "UPDATE SUBSCRIPTIONS SET REMINDER_LEAD = NULLIF($3, 0), REMINDER_SENT = FALSE WHERE TELEGRAM_USER_ID = $1 AND ANIME_ID = $2"
+	findDueReminderSQL    = "SELECT SS.TELEGRAM_USER_ID, SS.ANIME_ID FROM SUBSCRIPTIONS AS SS" +
+		" JOIN ANIMES AS ANS ON (SS.ANIME_ID = ANS.ID) JOIN TELEGRAM_USERS AS TU ON (SS.TELEGRAM_USER_ID = TU.ID)" +
+		" WHERE SS.REMINDER_LEAD IS NOT NULL AND SS.REMINDER_SENT = FALSE AND TU.ACTIVE = TRUE AND TU.BLOCKED = FALSE AND ANS.NOTIFICATION_SENT = FALSE" +
+		" AND ANS.NEXT_EPISODE_AT > NOW() AND ANS.NEXT_EPISODE_AT - SS.REMINDER_LEAD * INTERVAL '1 second' <= NOW()" +
+		" LIMIT 1 FOR UPDATE OF SS SKIP LOCKED"
+	findAnimeByIDSQL      = "SELECT ID, EXTERNALID, RUSNAME, ENGNAME, IMAGEURL, NEXT_EPISODE_AT, NOTIFICATION_SENT FROM ANIMES WHERE ID = $1"
+	findSubscriberByIDSQL = "SELECT TU.ID, TU.TELEGRAM_USER_ID, TU.TELEGRAM_USERNAME, TU.ACTIVE, TU.LOCALE, TU.TIMEZONE," +
+		" COALESCE(UP.QUIET_FROM, 0), COALESCE(UP.QUIET_TO, 0) FROM TELEGRAM_USERS AS TU" +
+		" LEFT JOIN USER_PREFERENCES AS UP ON (UP.TELEGRAM_USER_ID = TU.ID) WHERE TU.ID = $1"
+	updateReminderSentSQL = "UPDATE SUBSCRIPTIONS SET REMINDER_SENT = TRUE WHERE TELEGRAM_USER_ID = $1 AND ANIME_ID = $2"
+	resetRemindersSentSQL = "UPDATE SUBSCRIPTIONS SET REMINDER_SENT = FALSE WHERE ANIME_ID = $1"
+)
+
+//SetReminder func sets reminder lead time in seconds for subscription, zero lead disables reminder.
+//Returns false when the user is not subscribed to the anime
+func (sdao *SubscriptionDAO) SetReminder(userID int64, animeID int64, lead int64) (bool, error) {
+	sqlStatement, stmtErr := sdao.Db.Prepare(updateReminderLeadSQL)
+	if stmtErr != nil {
+		return false, errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	result, resErr := sqlStatement.Exec(userID, animeID, lead)
+	if resErr != nil {
+		return false, errors.WithStack(resErr)
+	}
+	affected, affectedErr := result.RowsAffected()
+	if affectedErr != nil {
+		return false, errors.WithStack(affectedErr)
+	}
+	return affected > 0, nil
+}
+
+//RemindUpcoming func processes every subscription whose reminder lead time before NEXT_EPISODE_AT has come.
+//Each subscription is handled in its own transaction: REMINDER_SENT is flipped and notification returned by remind
+//is written to outbox or held before commit, so a failed remind leaves the subscription pending for the next run.
+//REMINDER_SENT is reset when the episode is released
+func (sdao *SubscriptionDAO) RemindUpcoming(remind func(anime *AnimeDTO, subscriber *SubscriberDTO) (*Notification, error)) (int, error) {
+	count := 0
+	for {
+		processed, err := sdao.remindNextUpcoming(remind)
+		if err != nil {
+			return count, err
+		}
+		if !processed {
+			return count, nil
+		}
+		count++
+	}
+}
+
+func (sdao *SubscriptionDAO) remindNextUpcoming(remind func(anime *AnimeDTO, subscriber *SubscriberDTO) (*Notification, error)) (bool, error) {
+	tx, txErr := sdao.Db.Begin()
+	if txErr != nil {
+		return false, errors.WithStack(txErr)
+	}
+	processed, remindErr := sdao.remindUpcoming(tx, remind)
+	if remindErr != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return false, errors.WithStack(rollbackErr)
+		}
+		return false, remindErr
+	}
+	if commitErr := tx.Commit(); commitErr != nil {
+		return false, errors.WithStack(commitErr)
+	}
+	return processed, nil
+}
+
+func (sdao *SubscriptionDAO) remindUpcoming(tx *sql.Tx, remind func(anime *AnimeDTO, subscriber *SubscriberDTO) (*Notification, error)) (bool, error) {
+	userID, animeID, found, findErr := sdao.findDueReminder(tx)
+	if findErr != nil {
+		return false, findErr
+	}
+	if !found {
+		return false, nil
+	}
+	animeDTO, animeErr := sdao.findAnime(tx, animeID)
+	if animeErr != nil {
+		return false, animeErr
+	}
+	subscriberDTO, subscriberErr := sdao.findSubscriber(tx, userID)
+	if subscriberErr != nil {
+		return false, subscriberErr
+	}
+	if err := sdao.markReminderSent(tx, userID, animeID); err != nil {
+		return false, err
+	}
+	notification, remindErr := remind(animeDTO, subscriberDTO)
+	if remindErr != nil {
+		return false, remindErr
+	}
+	if err := insertNotification(tx, userID, notification); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (sdao *SubscriptionDAO) findAnime(tx *sql.Tx, animeID int64) (*AnimeDTO, error) {
+	sqlStatement, stmtErr := tx.Prepare(findAnimeByIDSQL)
+	if stmtErr != nil {
+		return nil, errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	result, resErr := sqlStatement.Query(animeID)
+	if resErr != nil {
+		return nil, errors.WithStack(resErr)
+	}
+	defer result.Close()
+	if !result.Next() {
+		return nil, errors.Errorf("Anime %d not found", animeID)
+	}
+	return scanAsAnime(result)
+}
+
+func (sdao *SubscriptionDAO) findSubscriber(tx *sql.Tx, userID int64) (*SubscriberDTO, error) {
+	sqlStatement, stmtErr := tx.Prepare(findSubscriberByIDSQL)
+	if stmtErr != nil {
+		return nil, errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	result, resErr := sqlStatement.Query(userID)
+	if resErr != nil {
+		return nil, errors.WithStack(resErr)
+	}
+	defer result.Close()
+	if !result.Next() {
+		return nil, errors.Errorf("User %d not found", userID)
+	}
+	return scanAsSubscriber(result)
+}
+
+func (sdao *SubscriptionDAO) markReminderSent(tx *sql.Tx, userID int64, animeID int64) error {
+	sqlStatement, stmtErr := tx.Prepare(updateReminderSentSQL)
+	if stmtErr != nil {
+		return errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	if _, resErr := sqlStatement.Exec(userID, animeID); resErr != nil {
+		return errors.WithStack(resErr)
+	}
+	return nil
+}
+
+func resetRemindersSent(tx *sql.Tx, animeID int64) error {
+	sqlStatement, stmtErr := tx.Prepare(resetRemindersSentSQL)
+	if stmtErr != nil {
+		return errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	if _, resErr := sqlStatement.Exec(animeID); resErr != nil {
+		return errors.WithStack(resErr)
+	}
+	return nil
+}
+
+func (sdao *SubscriptionDAO) findDueReminder(tx *sql.Tx) (int64, int64, bool, error) {
+	sqlStatement, stmtErr := tx.Prepare(findDueReminderSQL)
+	if stmtErr != nil {
+		return 0, 0, false, errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	result, resErr := sqlStatement.Query()
+	if resErr != nil {
+		return 0, 0, false, errors.WithStack(resErr)
+	}
+	defer result.Close()
+	if !result.Next() {
+		return 0, 0, false, nil
+	}
+	var userID, animeID sql.NullInt64
+	if scanErr := result.Scan(&userID, &animeID); scanErr != nil {
+		return 0, 0, false, errors.WithStack(scanErr)
+	}
+	return userID.Int64, animeID.Int64, true, nil
+}
@@ -0,0 +1,103 @@
+package dao
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+//searchUserAnimesSQL ranks exact matches first, then prefix matches, then the rest by trigram word similarity.
+//$2, $3 and $4 are the search term as typed, transliterated to cyrillic and transliterated to latin.
+//$5, $6, $7 are the same terms escaped as LIKE patterns
+const searchUserAnimesSQL = "SELECT ANS.ID, ANS.EXTERNALID, ANS.RUSNAME, ANS.ENGNAME, ANS.IMAGEURL, ANS.NEXT_EPISODE_AT, ANS.NOTIFICATION_SENT, SS.ANIME_ID FROM ANIMES AS ANS" +
+	" LEFT JOIN SUBSCRIPTIONS AS SS ON (ANS.ID = SS.ANIME_ID AND SS.TELEGRAM_USER_ID = $1)" +
+	" WHERE LOWER(ANS.ENGNAME) LIKE '%' || $5::TEXT || '%' OR LOWER(ANS.RUSNAME) LIKE '%' || $5::TEXT || '%'" +
+	" OR LOWER(ANS.ENGNAME) LIKE '%' || $6::TEXT || '%' OR LOWER(ANS.RUSNAME) LIKE '%' || $6::TEXT || '%'" +
+	" OR LOWER(ANS.ENGNAME) LIKE '%' || $7::TEXT || '%' OR LOWER(ANS.RUSNAME) LIKE '%' || $7::TEXT || '%'" +
+	" OR $2::TEXT <% LOWER(ANS.ENGNAME) OR $2::TEXT <% LOWER(ANS.RUSNAME)" +
+	" OR $3::TEXT <% LOWER(ANS.ENGNAME) OR $3::TEXT <% LOWER(ANS.RUSNAME)" +
+	" OR $4::TEXT <% LOWER(ANS.ENGNAME) OR $4::TEXT <% LOWER(ANS.RUSNAME)" +
+	" OR TO_TSVECTOR('simple', ANS.ENGNAME || ' ' || ANS.RUSNAME) @@ PLAINTO_TSQUERY('simple', $2::TEXT)" +
+	" OR TO_TSVECTOR('simple', ANS.ENGNAME || ' ' || ANS.RUSNAME) @@ PLAINTO_TSQUERY('simple', $3::TEXT)" +
+	" OR TO_TSVECTOR('simple', ANS.ENGNAME || ' ' || ANS.RUSNAME) @@ PLAINTO_TSQUERY('simple', $4::TEXT)" +
+	" ORDER BY CASE" +
+	" WHEN LOWER(ANS.ENGNAME) IN ($2::TEXT, $3::TEXT, $4::TEXT) OR LOWER(ANS.RUSNAME) IN ($2::TEXT, $3::TEXT, $4::TEXT) THEN 0" +
+	" WHEN LOWER(ANS.ENGNAME) LIKE $5::TEXT || '%' OR LOWER(ANS.RUSNAME) LIKE $5::TEXT || '%'" +
+	" OR LOWER(ANS.ENGNAME) LIKE $6::TEXT || '%' OR LOWER(ANS.RUSNAME) LIKE $6::TEXT || '%'" +
+	" OR LOWER(ANS.ENGNAME) LIKE $7::TEXT || '%' OR LOWER(ANS.RUSNAME) LIKE $7::TEXT || '%' THEN 1" +
+	" ELSE 2 END," +
+	" GREATEST(WORD_SIMILARITY($2::TEXT, LOWER(ANS.ENGNAME)), WORD_SIMILARITY($2::TEXT, LOWER(ANS.RUSNAME))," +
+	" WORD_SIMILARITY($3::TEXT, LOWER(ANS.ENGNAME)), WORD_SIMILARITY($3::TEXT, LOWER(ANS.RUSNAME))," +
+	" WORD_SIMILARITY($4::TEXT, LOWER(ANS.ENGNAME)), WORD_SIMILARITY($4::TEXT, LOWER(ANS.RUSNAME))) DESC, ANS.ID LIMIT $8 OFFSET $9"
+
+//SearchUserAnimes func returns at most pageSize animes matching sentence ordered by relevance.
+//Sentence is also matched in its latin and cyrillic transliterations.
+//Second result reports whether more animes exist after the returned ones
+func (adao *AnimeDAO) SearchUserAnimes(internalUserID int64, sentence string, offset int64) ([]UserAnimeDTO, bool, error) {
+	term := strings.ToLower(strings.TrimSpace(sentence))
+	terms := []string{term, toCyrillic(term), toLatin(term)}
+	args := []interface{}{internalUserID}
+	for _, t := range terms {
+		args = append(args, t)
+	}
+	for _, t := range terms {
+		args = append(args, likeEscaper.Replace(t))
+	}
+	return adao.readUserAnimesBySQL(searchUserAnimesSQL, offset, args...)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya",
+}
+
+//latinToCyrillic is ordered from the longest combination to the shortest one
+var latinToCyrillic = []struct {
+	latin    string
+	cyrillic string
+}{
+	{"shch", "щ"},
+	{"sh", "ш"}, {"ch", "ч"}, {"zh", "ж"}, {"kh", "х"}, {"ts", "ц"},
+	{"yo", "ё"}, {"yu", "ю"}, {"ya", "я"}, {"ye", "е"},
+	{"a", "а"}, {"b", "б"}, {"c", "к"}, {"d", "д"}, {"e", "е"}, {"f", "ф"}, {"g", "г"},
+	{"h", "х"}, {"i", "и"}, {"j", "дж"}, {"k", "к"}, {"l", "л"}, {"m", "м"}, {"n", "н"},
+	{"o", "о"}, {"p", "п"}, {"q", "к"}, {"r", "р"}, {"s", "с"}, {"t", "т"}, {"u", "у"},
+	{"v", "в"}, {"w", "в"}, {"x", "кс"}, {"y", "й"}, {"z", "з"},
+}
+
+func toLatin(sentence string) string {
+	builder := new(strings.Builder)
+	for _, r := range sentence {
+		if latin, ok := cyrillicToLatin[r]; ok {
+			builder.WriteString(latin)
+		} else {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+func toCyrillic(sentence string) string {
+	builder := new(strings.Builder)
+	for len(sentence) > 0 {
+		matched := false
+		for _, pair := range latinToCyrillic {
+			if strings.HasPrefix(sentence, pair.latin) {
+				builder.WriteString(pair.cyrillic)
+				sentence = sentence[len(pair.latin):]
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			r, size := utf8.DecodeRuneInString(sentence)
+			builder.WriteRune(r)
+			sentence = sentence[size:]
+		}
+	}
+	return builder.String()
+}
@@ -0,0 +1,69 @@
+package dao
+
+import "testing"
+
+func TestToLatin(t *testing.T) {
+	cases := []struct {
+		sentence string
+		expected string
+	}{
+		{"", ""},
+		{"наруто", "naruto"},
+		{"ёжик в тумане", "yozhik v tumane"},
+		{"щука и чайка", "shchuka i chayka"},
+		{"подъезд", "podezd"},
+		{"цветы юности", "tsvety yunosti"},
+		{"хвост феи", "khvost fei"},
+		{"naruto", "naruto"},
+		{"ван-пис 2", "van-pis 2"},
+	}
+	for _, c := range cases {
+		if actual := toLatin(c.sentence); actual != c.expected {
+			t.Errorf("toLatin(%q) = %q, expected %q", c.sentence, actual, c.expected)
+		}
+	}
+}
+
+func TestToCyrillic(t *testing.T) {
+	cases := []struct {
+		sentence string
+		expected string
+	}{
+		{"", ""},
+		{"naruto", "наруто"},
+		{"shchuka", "щука"},
+		{"shamisen", "шамисен"},
+		{"chainsaw", "чаинсав"},
+		{"zhizn", "жизн"},
+		{"yozhik", "ёжик"},
+		{"yuri", "юри"},
+		{"jojo", "джоджо"},
+		{"xxx", "кскскс"},
+		{"наруто", "наруто"},
+		{"one-piece 2", "оне-пиеке 2"},
+	}
+	for _, c := range cases {
+		if actual := toCyrillic(c.sentence); actual != c.expected {
+			t.Errorf("toCyrillic(%q) = %q, expected %q", c.sentence, actual, c.expected)
+		}
+	}
+}
+
+func TestLikeEscaper(t *testing.T) {
+	cases := []struct {
+		term     string
+		expected string
+	}{
+		{"", ""},
+		{"naruto", "naruto"},
+		{"100%", `100\%`},
+		{"re_zero", `re\_zero`},
+		{`a\b`, `a\\b`},
+		{`%_\`, `\%\_\\`},
+	}
+	for _, c := range cases {
+		if actual := likeEscaper.Replace(c.term); actual != c.expected {
+			t.Errorf("likeEscaper.Replace(%q) = %q, expected %q", c.term, actual, c.expected)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package dao
+
+import (
+	sql "database/sql"
+
+	"github.com/pkg/errors"
+)
+
+//UpdateDAO struct stores update_id of handled Telegram updates
+type UpdateDAO struct {
+	Db *sql.DB
+}
+
+const (
+	markUpdateProcessedSQL = "INSERT INTO PROCESSED_UPDATES (UPDATE_ID, PROCESSED_AT) VALUES($1, NOW())" +
+		" ON CONFLICT (UPDATE_ID) DO UPDATE SET PROCESSED_AT = NOW() WHERE PROCESSED_UPDATES.PROCESSED_AT < NOW() - $2::INTEGER * INTERVAL '1 second'"
+	deleteProcessedUpdateSQL = "DELETE FROM PROCESSED_UPDATES WHERE UPDATE_ID = $1"
+	deleteExpiredUpdatesSQL  = "DELETE FROM PROCESSED_UPDATES WHERE PROCESSED_AT < NOW() - $1::INTEGER * INTERVAL '1 second'"
+)
+
+//MarkProcessed func records update_id and reports false when it was already recorded less than ttl seconds ago
+func (udao *UpdateDAO) MarkProcessed(updateID int64, ttl int) (bool, error) {
+	sqlStatement, stmtErr := udao.Db.Prepare(markUpdateProcessedSQL)
+	if stmtErr != nil {
+		return false, errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	result, resErr := sqlStatement.Exec(updateID, ttl)
+	if resErr != nil {
+		return false, errors.WithStack(resErr)
+	}
+	affected, affectedErr := result.RowsAffected()
+	if affectedErr != nil {
+		return false, errors.WithStack(affectedErr)
+	}
+	return affected > 0, nil
+}
+
+//Delete func
+func (udao *UpdateDAO) Delete(updateID int64) error {
+	return udao.exec(deleteProcessedUpdateSQL, updateID)
+}
+
+//DeleteExpired func removes update_ids recorded more than ttl seconds ago
+func (udao *UpdateDAO) DeleteExpired(ttl int) error {
+	return udao.exec(deleteExpiredUpdatesSQL, ttl)
+}
+
+func (udao *UpdateDAO) exec(sqlStr string, args ...interface{}) error {
+	sqlStatement, stmtErr := udao.Db.Prepare(sqlStr)
+	if stmtErr != nil {
+		return errors.WithStack(stmtErr)
+	}
+	defer sqlStatement.Close()
+	if _, resErr := sqlStatement.Exec(args...); resErr != nil {
+		return errors.WithStack(resErr)
+	}
+	return nil
+}
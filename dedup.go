@@ -0,0 +1,128 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/HDIOES/anime-app/dao"
+)
+
+//Update stores, memory store does not see update_ids handled by other replicas
+const (
+	memoryUpdateStore   = "memory"
+	postgresUpdateStore = "postgres"
+)
+
+//UpdateStore interface remembers handled update_ids, so redelivered updates can be skipped
+type UpdateStore interface {
+	//MarkProcessed records update_id and reports false when it has been recorded before
+	MarkProcessed(updateID int64) (bool, error)
+	//Forget removes update_id, so redelivery of the update is handled again
+	Forget(updateID int64) error
+}
+
+//newUpdateStore func creates UpdateStore chosen by UpdateStore setting. When it is not set, PROCESSED_UPDATES table is used
+//if updates are consumed from NATS, because a redelivered update may go to another replica of the queue group
+func newUpdateStore(settings *Settings, udao *dao.UpdateDAO) UpdateStore {
+	switch settings.UpdateStore {
+	case postgresUpdateStore:
+	case memoryUpdateStore:
+		return NewMemoryUpdateStore(time.Duration(settings.UpdateTTL) * time.Second)
+	case "":
+		if settings.InboundSubject == "" {
+			return NewMemoryUpdateStore(time.Duration(settings.UpdateTTL) * time.Second)
+		}
+	default:
+		log.Panicln("Unknown update store: ", settings.UpdateStore)
+	}
+	return NewPostgresUpdateStore(udao, settings.UpdateTTL)
+}
+
+//MemoryUpdateStore struct keeps update_ids in memory of the current process
+type MemoryUpdateStore struct {
+	ttl         time.Duration
+	mutex       sync.Mutex
+	processed   map[int64]time.Time
+	lastCleanup time.Time
+}
+
+//NewMemoryUpdateStore func
+func NewMemoryUpdateStore(ttl time.Duration) *MemoryUpdateStore {
+	return &MemoryUpdateStore{
+		ttl:         ttl,
+		processed:   make(map[int64]time.Time),
+		lastCleanup: time.Now(),
+	}
+}
+
+//MarkProcessed func
+func (mus *MemoryUpdateStore) MarkProcessed(updateID int64) (bool, error) {
+	mus.mutex.Lock()
+	defer mus.mutex.Unlock()
+	now := time.Now()
+	if now.Sub(mus.lastCleanup) > mus.ttl {
+		for id, processedAt := range mus.processed {
+			if now.Sub(processedAt) > mus.ttl {
+				delete(mus.processed, id)
+			}
+		}
+		mus.lastCleanup = now
+	}
+	if processedAt, ok := mus.processed[updateID]; ok && now.Sub(processedAt) <= mus.ttl {
+		return false, nil
+	}
+	mus.processed[updateID] = now
+	return true, nil
+}
+
+//Forget func
+func (mus *MemoryUpdateStore) Forget(updateID int64) error {
+	mus.mutex.Lock()
+	defer mus.mutex.Unlock()
+	delete(mus.processed, updateID)
+	return nil
+}
+
+//PostgresUpdateStore struct keeps update_ids in PROCESSED_UPDATES table, so it is shared by all replicas
+type PostgresUpdateStore struct {
+	udao        *dao.UpdateDAO
+	ttl         int
+	mutex       sync.Mutex
+	lastCleanup time.Time
+}
+
+//NewPostgresUpdateStore func, ttl is in seconds
+func NewPostgresUpdateStore(udao *dao.UpdateDAO, ttl int) *PostgresUpdateStore {
+	return &PostgresUpdateStore{
+		udao:        udao,
+		ttl:         ttl,
+		lastCleanup: time.Now(),
+	}
+}
+
+//MarkProcessed func
+func (pus *PostgresUpdateStore) MarkProcessed(updateID int64) (bool, error) {
+	if pus.needsCleanup() {
+		if err := pus.udao.DeleteExpired(pus.ttl); err != nil {
+			HandleError(err)
+		}
+	}
+	return pus.udao.MarkProcessed(updateID, pus.ttl)
+}
+
+//Forget func
+func (pus *PostgresUpdateStore) Forget(updateID int64) error {
+	return pus.udao.Delete(updateID)
+}
+
+func (pus *PostgresUpdateStore) needsCleanup() bool {
+	pus.mutex.Lock()
+	defer pus.mutex.Unlock()
+	now := time.Now()
+	if now.Sub(pus.lastCleanup) > time.Duration(pus.ttl)*time.Second {
+		pus.lastCleanup = now
+		return true
+	}
+	return false
+}
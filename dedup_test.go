@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/HDIOES/anime-app/dao"
+)
+
+func TestNewUpdateStoreSharesUpdatesInConsumerMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings Settings
+		postgres bool
+	}{
+		{name: "webhook", settings: Settings{}},
+		{name: "consumer", settings: Settings{InboundSubject: "updates"}, postgres: true},
+		{name: "memory in consumer mode", settings: Settings{InboundSubject: "updates", UpdateStore: memoryUpdateStore}},
+		{name: "postgres", settings: Settings{UpdateStore: postgresUpdateStore}, postgres: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			settings := test.settings
+			_, postgres := newUpdateStore(&settings, &dao.UpdateDAO{}).(*PostgresUpdateStore)
+			if postgres != test.postgres {
+				t.Fatalf("update store is postgres: %t, want %t", postgres, test.postgres)
+			}
+		})
+	}
+}
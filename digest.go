@@ -0,0 +1,91 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HDIOES/anime-app/dao"
+	"github.com/HDIOES/anime-app/i18n"
+)
+
+//DigestBuilder struct queues daily and weekly digests of released episodes
+type DigestBuilder struct {
+	pdao     *dao.PreferencesDAO
+	sender   MessageSender
+	settings *Settings
+	catalog  *i18n.Catalog
+}
+
+//Build func queues digest for every user whose digest time has come, returns count of processed users
+func (dg *DigestBuilder) Build(now time.Time) (int, error) {
+	users, err := dg.pdao.ReadDigestUsers()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for i := range users {
+		user := &users[i]
+		location, _ := userTimezone(dg.settings, user.Timezone)
+		if !digestDue(&user.Preferences, now, location) {
+			continue
+		}
+		buildErr := dg.pdao.BuildDigest(user.ID, func(releases []dao.AnimeDTO) (*dao.OutboxMessage, error) {
+			return dg.encodeDigest(&user.UserDTO, releases)
+		})
+		if buildErr != nil {
+			return count, buildErr
+		}
+		count++
+	}
+	return count, nil
+}
+
+//digestDue func checks whether the last digest was sent before the latest scheduled time,
+//which is DigestHour of today for daily mode and of Monday for weekly mode in the user's timezone
+func digestDue(preferences *dao.PreferencesDTO, now time.Time, location *time.Location) bool {
+	local := now.In(location)
+	scheduled := time.Date(local.Year(), local.Month(), local.Day(), preferences.DigestHour, 0, 0, 0, location)
+	period := 1
+	if preferences.NotificationMode == dao.WeeklyMode {
+		period = 7
+		scheduled = scheduled.AddDate(0, 0, -((int(local.Weekday()) + 6) % 7))
+	}
+	if local.Before(scheduled) {
+		scheduled = scheduled.AddDate(0, 0, -period)
+	}
+	return preferences.LastDigestAt.Before(scheduled)
+}
+
+func (dg *DigestBuilder) encodeDigest(user *dao.UserDTO, releases []dao.AnimeDTO) (*dao.OutboxMessage, error) {
+	if len(releases) == 0 {
+		return nil, nil
+	}
+	telegramID, parseErr := strconv.ParseInt(user.ExternalID, 10, 64)
+	if parseErr != nil {
+		HandleError(errors.WithStack(parseErr))
+		return nil, nil
+	}
+	location, timezoneName := userTimezone(dg.settings, user.Timezone)
+	inlineAnimes := make([]InlineAnime, 0, len(releases))
+	for i := range releases {
+		anime := &releases[i]
+		inlineAnimes = append(inlineAnimes, InlineAnime{
+			InternalID:           anime.ID,
+			AnimeName:            animeName(user.Locale, anime),
+			AnimeThumbnailPicURL: dg.settings.ShikimoriURL + anime.ImageURL,
+			UserHasSubscription:  true,
+			AirTime:              dg.catalog.Format(user.Locale, airedAtKey, formatAirTime(anime.NextEpisodeAt, location, timezoneName)),
+		})
+	}
+	ntsMessage := TelegramCommandMessage{
+		TelegramID:   telegramID,
+		Type:         digestType,
+		Text:         dg.catalog.Text(user.Locale, digestKey),
+		InlineAnimes: inlineAnimes,
+	}
+	log.Printf("Digest with %d episodes built for user %d\n", len(releases), user.ID)
+	return dg.sender.Encode(&ntsMessage)
+}
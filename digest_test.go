@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/HDIOES/anime-app/dao"
+)
+
+func TestDigestDue(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	newYork := time.FixedZone("EST", -5*60*60)
+	//2024-01-01 is Monday
+	tests := []struct {
+		name         string
+		mode         string
+		now          time.Time
+		lastDigestAt time.Time
+		location     *time.Location
+		due          bool
+	}{
+		{"weekly on monday before digest hour", dao.WeeklyMode, time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC), time.Date(2023, 12, 25, 9, 5, 0, 0, time.UTC), time.UTC, false},
+		{"weekly on monday after digest hour", dao.WeeklyMode, time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC), time.Date(2023, 12, 25, 9, 5, 0, 0, time.UTC), time.UTC, true},
+		{"weekly on monday after digest is sent", dao.WeeklyMode, time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC), time.Date(2024, 1, 1, 9, 10, 0, 0, time.UTC), time.UTC, false},
+		{"weekly on sunday", dao.WeeklyMode, time.Date(2023, 12, 31, 20, 0, 0, 0, time.UTC), time.Date(2023, 12, 25, 9, 5, 0, 0, time.UTC), time.UTC, false},
+		{"weekly on sunday after missed monday", dao.WeeklyMode, time.Date(2023, 12, 31, 20, 0, 0, 0, time.UTC), time.Date(2023, 12, 18, 9, 5, 0, 0, time.UTC), time.UTC, true},
+		{"daily before digest hour", dao.DailyMode, time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC), time.Date(2023, 12, 31, 9, 0, 0, 0, time.UTC), time.UTC, false},
+		{"daily at digest hour", dao.DailyMode, time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), time.Date(2023, 12, 31, 9, 0, 0, 0, time.UTC), time.UTC, true},
+		{"weekly on monday before digest hour in moscow", dao.WeeklyMode, time.Date(2024, 1, 1, 5, 30, 0, 0, time.UTC), time.Date(2023, 12, 25, 7, 0, 0, 0, time.UTC), moscow, false},
+		{"weekly on monday after digest hour in moscow", dao.WeeklyMode, time.Date(2024, 1, 1, 7, 0, 0, 0, time.UTC), time.Date(2023, 12, 25, 7, 0, 0, 0, time.UTC), moscow, true},
+		{"weekly on monday in new york while tuesday in utc", dao.WeeklyMode, time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), newYork, true},
+		{"daily in new york while next day in utc", dao.DailyMode, time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 15, 0, 0, 0, time.UTC), newYork, false},
+	}
+	for _, test := range tests {
+		preferences := &dao.PreferencesDTO{NotificationMode: test.mode, DigestHour: 9, LastDigestAt: test.lastDigestAt}
+		if due := digestDue(preferences, test.now, test.location); due != test.due {
+			t.Errorf("%s: digest due is %t, want %t", test.name, due, test.due)
+		}
+	}
+}
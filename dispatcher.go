@@ -0,0 +1,34 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"sync/atomic"
+)
+
+//UpdateDispatcher interface processes decoded Telegram updates regardless of transport they came from.
+//Returned error decides whether the transport should redeliver the update, see statusCode
+type UpdateDispatcher interface {
+	Dispatch(update *Update) error
+}
+
+//Dispatch func skips updates which have been handled before.
+//Update is forgotten when it fails with transient error, so its redelivery is handled again
+func (th *TelegramHandler) Dispatch(update *Update) error {
+	isNew, markErr := th.updateStore.MarkProcessed(update.UpdateID)
+	if markErr != nil {
+		return newTransientError(markErr)
+	}
+	if !isNew {
+		dropped := atomic.AddUint64(&th.droppedDuplicates, 1)
+		log.Printf("Duplicate update %d dropped, %d duplicates dropped so far\n", update.UpdateID, dropped)
+		return nil
+	}
+	err := th.dispatchUpdate(update)
+	if err != nil && statusCode(err) >= http.StatusInternalServerError {
+		if forgetErr := th.updateStore.Forget(update.UpdateID); forgetErr != nil {
+			HandleError(forgetErr)
+		}
+	}
+	return err
+}
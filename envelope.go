@@ -0,0 +1,252 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HDIOES/anime-app/dao"
+)
+
+//Message contracts of outbound NATS messages. Legacy contract is flat TelegramCommandMessage,
+//envelope contract wraps type-specific payload described by JSON Schemas in schemas directory
+const (
+	legacyContract   = "legacy"
+	envelopeContract = "envelope"
+)
+
+const envelopeSchemaVersion = 2
+
+//Envelope struct
+type Envelope struct {
+	SchemaVersion int         `json:"schemaVersion"`
+	MessageID     string      `json:"messageId"`
+	Timestamp     time.Time   `json:"timestamp"`
+	Type          string      `json:"type"`
+	Payload       interface{} `json:"payload"`
+}
+
+//ChatMessagePayload struct is payload of startType, defaultType, confirmationType, languageType,
+//notificationType and reminderType messages
+type ChatMessagePayload struct {
+	TelegramID int64           `json:"telegramId"`
+	Text       string          `json:"text"`
+	Anime      *AnimePayload   `json:"anime,omitempty"`
+	Buttons    []ButtonPayload `json:"buttons,omitempty"`
+}
+
+//DigestPayload struct
+type DigestPayload struct {
+	TelegramID int64          `json:"telegramId"`
+	Text       string         `json:"text"`
+	Animes     []AnimePayload `json:"animes"`
+}
+
+//ListPayload struct, chatId, messageId and callbackQueryId are set when page is requested from callback button
+type ListPayload struct {
+	TelegramID      int64          `json:"telegramId"`
+	Text            string         `json:"text"`
+	Animes          []AnimePayload `json:"animes"`
+	Page            int64          `json:"page"`
+	HasNextPage     bool           `json:"hasNextPage"`
+	ChatID          int64          `json:"chatId,omitempty"`
+	MessageID       int64          `json:"messageId,omitempty"`
+	CallbackQueryID string         `json:"callbackQueryId,omitempty"`
+}
+
+//AnswerQueryPayload struct
+type AnswerQueryPayload struct {
+	InlineQueryID string         `json:"inlineQueryId"`
+	Animes        []AnimePayload `json:"animes"`
+	NextOffset    string         `json:"nextOffset,omitempty"`
+}
+
+//SubscriptionPayload struct is payload of subscribeType and unsubscribeType messages
+type SubscriptionPayload struct {
+	ChatID          int64  `json:"chatId"`
+	MessageID       int64  `json:"messageId"`
+	CallbackQueryID string `json:"callbackQueryId"`
+	AnimeID         int64  `json:"animeId"`
+}
+
+//EditTextPayload struct
+type EditTextPayload struct {
+	ChatID          int64  `json:"chatId"`
+	MessageID       int64  `json:"messageId"`
+	CallbackQueryID string `json:"callbackQueryId"`
+	Text            string `json:"text"`
+}
+
+//AnswerCallbackPayload struct
+type AnswerCallbackPayload struct {
+	CallbackQueryID string `json:"callbackQueryId"`
+	Text            string `json:"text"`
+}
+
+//AnimePayload struct
+type AnimePayload struct {
+	ID            int64      `json:"id"`
+	Name          string     `json:"name"`
+	ThumbnailURL  string     `json:"thumbnailUrl"`
+	Subscribed    bool       `json:"subscribed"`
+	NextEpisodeAt *time.Time `json:"nextEpisodeAt,omitempty"`
+	AirTime       string     `json:"airTime,omitempty"`
+}
+
+//ButtonPayload struct
+type ButtonPayload struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callbackData"`
+}
+
+//encodeNtsMessage func marshals message according to MessageEncoding and MessageContract from Settings.
+//Protobuf encoding always uses envelope contract. MessageID of the result is messageId of the envelope
+//or a new one for legacy contract, JetStream drops repeated publications with the same id
+func encodeNtsMessage(settings *Settings, ntsMessage *TelegramCommandMessage) (*dao.OutboxMessage, error) {
+	if settings.MessageEncoding == protobufEncoding {
+		envelope, envelopeErr := newEnvelope(ntsMessage)
+		if envelopeErr != nil {
+			return nil, envelopeErr
+		}
+		data, dataErr := marshalProto(envelope)
+		if dataErr != nil {
+			return nil, dataErr
+		}
+		return &dao.OutboxMessage{ContentType: protobufContentType, Data: data, MessageID: envelope.MessageID}, nil
+	}
+	var value interface{} = ntsMessage
+	var messageID string
+	if settings.MessageContract == envelopeContract {
+		envelope, envelopeErr := newEnvelope(ntsMessage)
+		if envelopeErr != nil {
+			return nil, envelopeErr
+		}
+		value = envelope
+		messageID = envelope.MessageID
+	} else {
+		var idErr error
+		if messageID, idErr = newMessageID(); idErr != nil {
+			return nil, idErr
+		}
+	}
+	data, dataErr := json.Marshal(value)
+	if dataErr != nil {
+		return nil, errors.WithStack(dataErr)
+	}
+	return &dao.OutboxMessage{ContentType: jsonContentType, Data: data, MessageID: messageID}, nil
+}
+
+func newEnvelope(ntsMessage *TelegramCommandMessage) (*Envelope, error) {
+	messageID, idErr := newMessageID()
+	if idErr != nil {
+		return nil, idErr
+	}
+	envelope := &Envelope{
+		SchemaVersion: envelopeSchemaVersion,
+		MessageID:     messageID,
+		Timestamp:     time.Now().UTC(),
+		Type:          ntsMessage.Type,
+	}
+	switch ntsMessage.Type {
+	case startType, defaultType, confirmationType, languageType, notificationType, reminderType:
+		payload := &ChatMessagePayload{
+			TelegramID: ntsMessage.TelegramID,
+			Text:       ntsMessage.Text,
+			Buttons:    buttonPayloads(ntsMessage.InlineButtons),
+		}
+		if ntsMessage.InlineAnime != nil {
+			anime := animePayload(ntsMessage.InlineAnime)
+			payload.Anime = &anime
+		}
+		envelope.Payload = payload
+	case digestType:
+		envelope.Payload = &DigestPayload{
+			TelegramID: ntsMessage.TelegramID,
+			Text:       ntsMessage.Text,
+			Animes:     animePayloads(ntsMessage.InlineAnimes),
+		}
+	case listType:
+		envelope.Payload = &ListPayload{
+			TelegramID:      ntsMessage.TelegramID,
+			Text:            ntsMessage.Text,
+			Animes:          animePayloads(ntsMessage.InlineAnimes),
+			Page:            ntsMessage.Page,
+			HasNextPage:     ntsMessage.HasNextPage,
+			ChatID:          ntsMessage.ChatID,
+			MessageID:       ntsMessage.MessageID,
+			CallbackQueryID: ntsMessage.CallbackQueryID,
+		}
+	case answerQueryType:
+		envelope.Payload = &AnswerQueryPayload{
+			InlineQueryID: ntsMessage.InlineQueryID,
+			Animes:        animePayloads(ntsMessage.InlineAnimes),
+			NextOffset:    ntsMessage.NextOffset,
+		}
+	case subscribeType, unsubscribeType:
+		envelope.Payload = &SubscriptionPayload{
+			ChatID:          ntsMessage.ChatID,
+			MessageID:       ntsMessage.MessageID,
+			CallbackQueryID: ntsMessage.CallbackQueryID,
+			AnimeID:         ntsMessage.InternalAnimeID,
+		}
+	case editTextType:
+		envelope.Payload = &EditTextPayload{
+			ChatID:          ntsMessage.ChatID,
+			MessageID:       ntsMessage.MessageID,
+			CallbackQueryID: ntsMessage.CallbackQueryID,
+			Text:            ntsMessage.Text,
+		}
+	case answerCallbackType:
+		envelope.Payload = &AnswerCallbackPayload{
+			CallbackQueryID: ntsMessage.CallbackQueryID,
+			Text:            ntsMessage.Text,
+		}
+	default:
+		return nil, errors.Errorf("Message type %s has no envelope payload", ntsMessage.Type)
+	}
+	return envelope, nil
+}
+
+func animePayload(inlineAnime *InlineAnime) AnimePayload {
+	return AnimePayload{
+		ID:            inlineAnime.InternalID,
+		Name:          inlineAnime.AnimeName,
+		ThumbnailURL:  inlineAnime.AnimeThumbnailPicURL,
+		Subscribed:    inlineAnime.UserHasSubscription,
+		NextEpisodeAt: inlineAnime.NextEpisodeAt,
+		AirTime:       inlineAnime.AirTime,
+	}
+}
+
+func animePayloads(inlineAnimes []InlineAnime) []AnimePayload {
+	animes := make([]AnimePayload, 0, len(inlineAnimes))
+	for i := range inlineAnimes {
+		animes = append(animes, animePayload(&inlineAnimes[i]))
+	}
+	return animes
+}
+
+func buttonPayloads(inlineButtons []InlineButton) []ButtonPayload {
+	if len(inlineButtons) == 0 {
+		return nil
+	}
+	buttons := make([]ButtonPayload, 0, len(inlineButtons))
+	for _, inlineButton := range inlineButtons {
+		buttons = append(buttons, ButtonPayload{Text: inlineButton.Text, CallbackData: inlineButton.CallbackData})
+	}
+	return buttons
+}
+
+//newMessageID func returns random UUID version 4
+func newMessageID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", errors.WithStack(err)
+	}
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:16]), nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+const envelopeSchemasDir = "schemas/v2"
+
+func TestNewEnvelopeMatchesSchemas(t *testing.T) {
+	nextEpisodeAt := time.Date(2021, 4, 10, 15, 30, 0, 500, time.UTC)
+	inlineAnime := InlineAnime{
+		InternalID:           7,
+		AnimeName:            "Shingeki no Kyojin",
+		AnimeThumbnailPicURL: "https://shikimori.one/7.jpg",
+		UserHasSubscription:  true,
+		NextEpisodeAt:        &nextEpisodeAt,
+		AirTime:              "Sat 18:30 MSK",
+	}
+	inlineButtons := []InlineButton{{Text: "Remind an hour before", CallbackData: "remind 7 3600"}}
+	messages := []TelegramCommandMessage{
+		{Type: startType, TelegramID: 42, Text: "Hello", InlineAnime: &inlineAnime, InlineButtons: inlineButtons},
+		{Type: startType, TelegramID: 42, Text: "Hello"},
+		{Type: defaultType, TelegramID: 42, Text: "Unknown command"},
+		{Type: confirmationType, TelegramID: 42, Text: "Sure?", InlineButtons: inlineButtons},
+		{Type: languageType, TelegramID: 42, Text: "Choose language", InlineButtons: inlineButtons},
+		{Type: notificationType, TelegramID: 42, Text: "New episode is out", InlineAnime: &inlineAnime},
+		{Type: reminderType, TelegramID: 42, Text: "New episode is coming soon", InlineAnime: &inlineAnime},
+		{Type: digestType, TelegramID: 42, Text: "Digest", InlineAnimes: []InlineAnime{inlineAnime}},
+		{Type: digestType, TelegramID: 42, Text: "Digest"},
+		{Type: listType, TelegramID: 42, Text: "Your subscriptions", InlineAnimes: []InlineAnime{inlineAnime}, Page: 1, HasNextPage: true},
+		{Type: listType, TelegramID: 42, Text: "Your subscriptions", Page: 2, ChatID: 42, MessageID: 5, CallbackQueryID: "query"},
+		{Type: answerQueryType, InlineQueryID: "inline", InlineAnimes: []InlineAnime{inlineAnime}, NextOffset: "50"},
+		{Type: answerQueryType, InlineQueryID: "inline"},
+		{Type: subscribeType, ChatID: 42, MessageID: 5, CallbackQueryID: "query", InternalAnimeID: 7},
+		{Type: unsubscribeType, ChatID: 42, MessageID: 5, CallbackQueryID: "query", InternalAnimeID: 7},
+		{Type: editTextType, ChatID: 42, MessageID: 5, CallbackQueryID: "query", Text: "Cancelled"},
+		{Type: answerCallbackType, CallbackQueryID: "query", Text: "Reminder is set"},
+	}
+	schemaFiles, globErr := filepath.Glob(filepath.Join(envelopeSchemasDir, "*.schema.json"))
+	if globErr != nil {
+		t.Fatal(globErr)
+	}
+	validated := make(map[string]bool, len(schemaFiles))
+	for i := range messages {
+		ntsMessage := &messages[i]
+		schema, compileErr := jsonschema.Compile(filepath.Join(envelopeSchemasDir, ntsMessage.Type+".schema.json"))
+		if compileErr != nil {
+			t.Fatalf("%s schema: %v", ntsMessage.Type, compileErr)
+		}
+		envelope, envelopeErr := newEnvelope(ntsMessage)
+		if envelopeErr != nil {
+			t.Fatal(envelopeErr)
+		}
+		data, marshalErr := json.Marshal(envelope)
+		if marshalErr != nil {
+			t.Fatal(marshalErr)
+		}
+		var document interface{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if decodeErr := decoder.Decode(&document); decodeErr != nil {
+			t.Fatal(decodeErr)
+		}
+		if validateErr := schema.Validate(document); validateErr != nil {
+			t.Errorf("%s envelope %s does not match its schema: %v", ntsMessage.Type, data, validateErr)
+		}
+		validated[ntsMessage.Type] = true
+	}
+	for _, schemaFile := range schemaFiles {
+		messageType := strings.TrimSuffix(filepath.Base(schemaFile), ".schema.json")
+		if !validated[messageType] {
+			t.Errorf("no %s message is validated against %s", messageType, schemaFile)
+		}
+	}
+}
+
+func TestEnvelopeSchemasRejectUnknownPayloadField(t *testing.T) {
+	schema, compileErr := jsonschema.Compile(filepath.Join(envelopeSchemasDir, startType+".schema.json"))
+	if compileErr != nil {
+		t.Fatal(compileErr)
+	}
+	document := map[string]interface{}{
+		"schemaVersion": json.Number("2"),
+		"messageId":     "5b8f7c4e-8e57-4a8e-9b51-4f1f5d3c2a10",
+		"timestamp":     "2021-04-10T15:30:00Z",
+		"type":          startType,
+		"payload":       map[string]interface{}{"telegramId": json.Number("42"), "text": "Hello", "chatId": json.Number("42")},
+	}
+	if schema.Validate(document) == nil {
+		t.Fatal("payload with unknown field matches the schema")
+	}
+}
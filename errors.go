@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+//ClientError is caused by content of the update itself, so retrying the update can not help
+type ClientError struct {
+	Err error
+}
+
+func newClientError(err error) error {
+	return &ClientError{Err: withStack(err)}
+}
+
+func (ce *ClientError) Error() string {
+	return ce.Err.Error()
+}
+
+//Unwrap func
+func (ce *ClientError) Unwrap() error {
+	return ce.Err
+}
+
+//StackTrace func
+func (ce *ClientError) StackTrace() errors.StackTrace {
+	return ce.Err.(StackTracer).StackTrace()
+}
+
+//TransientError is caused by unavailable database or NATS, so the update should be retried later
+type TransientError struct {
+	Err error
+}
+
+func newTransientError(err error) error {
+	return &TransientError{Err: withStack(err)}
+}
+
+func (te *TransientError) Error() string {
+	return te.Err.Error()
+}
+
+//Unwrap func
+func (te *TransientError) Unwrap() error {
+	return te.Err
+}
+
+//StackTrace func
+func (te *TransientError) StackTrace() errors.StackTrace {
+	return te.Err.(StackTracer).StackTrace()
+}
+
+func withStack(err error) error {
+	if _, ok := err.(StackTracer); ok {
+		return err
+	}
+	return errors.WithStack(err)
+}
+
+//statusCode func maps error to http status: updates we reject are acknowledged with 200 because Telegram
+//redelivers every update answered with another status, 5xx is for updates that should be redelivered
+func statusCode(err error) int {
+	var clientErr *ClientError
+	if errors.As(err, &clientErr) {
+		return http.StatusOK
+	}
+	var transientErr *TransientError
+	if errors.As(err, &transientErr) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	HandleError(err)
+	w.WriteHeader(statusCode(err))
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestStatusCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{name: "client error", err: newClientError(errors.New("Unknown command")), code: http.StatusOK},
+		{name: "transient error", err: newTransientError(errors.New("connection refused")), code: http.StatusServiceUnavailable},
+		{name: "wrapped client error", err: errors.Wrap(newClientError(errors.New("Parse error")), "list callback"), code: http.StatusOK},
+		{name: "wrapped transient error", err: fmt.Errorf("publish: %w", newTransientError(errors.New("no responders"))), code: http.StatusServiceUnavailable},
+		{
+			name: "retry after",
+			err:  newTransientError(&RetryAfterError{RetryAfter: time.Second, Err: errors.New("Too Many Requests")}),
+			code: http.StatusServiceUnavailable,
+		},
+		{name: "plain error", err: errors.New("unexpected"), code: http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if code := statusCode(test.err); code != test.code {
+				t.Fatalf("status code of %v is %d, want %d", test.err, code, test.code)
+			}
+		})
+	}
+}
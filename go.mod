@@ -0,0 +1,28 @@
+module github.com/HDIOES/anime-app
+
+go 1.17
+
+require (
+	github.com/lib/pq v1.10.0
+	github.com/nats-io/nats-server/v2 v2.6.1
+	github.com/nats-io/nats.go v1.12.3
+	github.com/pkg/errors v0.9.1
+	github.com/rubenv/sql-migrate v1.1.1
+	github.com/santhosh-tekuri/jsonschema/v5 v5.0.0
+	go.uber.org/dig v1.8.0
+	google.golang.org/protobuf v1.26.0
+)
+
+require (
+	github.com/go-gorp/gorp/v3 v3.0.2 // indirect
+	github.com/klauspost/compress v1.13.4 // indirect
+	github.com/minio/highwayhash v1.0.1 // indirect
+	github.com/nats-io/jwt/v2 v2.0.3 // indirect
+	github.com/nats-io/nkeys v0.3.0 // indirect
+	github.com/nats-io/nuid v1.0.1 // indirect
+	golang.org/x/crypto v0.0.0-20210616213533-5ff15b29337e // indirect
+	golang.org/x/lint v0.0.0-20210508222113-6edffad5e616 // indirect
+	golang.org/x/sys v0.0.0-20210809222454-d867a43fc93e // indirect
+	golang.org/x/time v0.0.0-20200416051211-89c76fbcd5d1 // indirect
+	golang.org/x/tools v0.1.7 // indirect
+)
@@ -6,19 +6,21 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+	"path"
 	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
 )
 
+//logRequest func logs request with parent of its path, the last segment may carry webhook secret
 func logRequest(request *http.Request) (io.Reader, error) {
 	logStringBuilder := new(strings.Builder)
 	logStringBuilder.WriteString("Http request:\n")
 	logStringBuilder.WriteString("Method ")
 	logStringBuilder.WriteString(request.Method)
 	logStringBuilder.WriteString(" ")
-	logStringBuilder.WriteString(request.URL.String())
+	logStringBuilder.WriteString(path.Dir(request.URL.Path))
 	logStringBuilder.WriteString("\n")
 	data, readErr := ioutil.ReadAll(request.Body)
 	if readErr != nil {
@@ -30,7 +32,7 @@ func logRequest(request *http.Request) (io.Reader, error) {
 	return bytes.NewBuffer(data), nil
 }
 
-func logResponse(response *http.Response) error {
+func logResponse(response *http.Response) (io.Reader, error) {
 	logStringBuilder := new(strings.Builder)
 	logStringBuilder.WriteString("Http response:\n")
 	logStringBuilder.WriteString("Http status: ")
@@ -38,10 +40,10 @@ func logResponse(response *http.Response) error {
 	logStringBuilder.WriteString("\n")
 	data, readErr := ioutil.ReadAll(response.Body)
 	if readErr != nil {
-		return errors.WithStack(readErr)
+		return nil, errors.WithStack(readErr)
 	}
 	logStringBuilder.Write(data)
 	logStringBuilder.WriteString("\n")
 	log.Print(logStringBuilder)
-	return nil
+	return bytes.NewBuffer(data), nil
 }
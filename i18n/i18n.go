@@ -0,0 +1,85 @@
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//Catalog struct holds bot texts for every supported locale
+type Catalog struct {
+	defaultLocale string
+	messages      map[string]map[string]string
+}
+
+//Load func reads every <locale>.json file from dir, each file maps message key to text
+func Load(dir string, defaultLocale string) (*Catalog, error) {
+	files, globErr := filepath.Glob(filepath.Join(dir, "*.json"))
+	if globErr != nil {
+		return nil, errors.WithStack(globErr)
+	}
+	catalog := &Catalog{
+		defaultLocale: defaultLocale,
+		messages:      make(map[string]map[string]string, len(files)),
+	}
+	for _, file := range files {
+		data, readErr := ioutil.ReadFile(file)
+		if readErr != nil {
+			return nil, errors.WithStack(readErr)
+		}
+		messages := make(map[string]string)
+		if decodeErr := json.Unmarshal(data, &messages); decodeErr != nil {
+			return nil, errors.Wrapf(decodeErr, "Catalog %s is malformed", file)
+		}
+		locale := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		catalog.messages[locale] = messages
+	}
+	if _, ok := catalog.messages[defaultLocale]; !ok {
+		return nil, errors.Errorf("Catalog for default locale %s not found in %s", defaultLocale, dir)
+	}
+	return catalog, nil
+}
+
+//Text func returns text of the message in locale, falling back to the default locale and then to the key itself
+func (c *Catalog) Text(locale, key string) string {
+	if text, ok := c.messages[locale][key]; ok {
+		return text
+	}
+	if text, ok := c.messages[c.defaultLocale][key]; ok {
+		return text
+	}
+	return key
+}
+
+//Format func formats text of the message in locale with args like fmt.Sprintf
+func (c *Catalog) Format(locale, key string, args ...interface{}) string {
+	return fmt.Sprintf(c.Text(locale, key), args...)
+}
+
+//Resolve func maps Telegram language_code like "en-US" to supported locale
+func (c *Catalog) Resolve(languageCode string) string {
+	languageCode = strings.ToLower(languageCode)
+	if _, ok := c.messages[languageCode]; ok {
+		return languageCode
+	}
+	language := strings.SplitN(languageCode, "-", 2)[0]
+	if _, ok := c.messages[language]; ok {
+		return language
+	}
+	return c.defaultLocale
+}
+
+//Locales func returns sorted supported locales
+func (c *Catalog) Locales() []string {
+	locales := make([]string, 0, len(c.messages))
+	for locale := range c.messages {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
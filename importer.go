@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HDIOES/anime-app/dao"
+)
+
+//shikimoriPageLimit is the max page size allowed by Shikimori API.
+//Shikimori returns one extra item when the next page exists
+const shikimoriPageLimit = 50
+
+//animeUpserter is the part of AnimeDAO used by ShikimoriImporter
+type animeUpserter interface {
+	Upsert(animeDTO *dao.AnimeDTO) error
+}
+
+//ShikimoriImporter struct
+type ShikimoriImporter struct {
+	adao     animeUpserter
+	client   *http.Client
+	settings *Settings
+}
+
+//Run func imports ongoings at startup and then every ImportInterval seconds
+func (si *ShikimoriImporter) Run() {
+	if err := si.importOngoings(); err != nil {
+		HandleError(err)
+	}
+	ticker := time.NewTicker(time.Duration(si.settings.ImportInterval) * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := si.importOngoings(); err != nil {
+			HandleError(err)
+		}
+	}
+}
+
+func (si *ShikimoriImporter) importOngoings() error {
+	nextEpisodes, calendarErr := si.readCalendar()
+	if calendarErr != nil {
+		return calendarErr
+	}
+	imported := 0
+	for page := 1; ; page++ {
+		animes, hasNextPage, pageErr := si.readOngoingsPage(page)
+		if pageErr != nil {
+			return pageErr
+		}
+		for _, anime := range animes {
+			nextEpisodeAt, ok := nextEpisodes[anime.ID]
+			if !ok {
+				continue
+			}
+			animeDTO := dao.AnimeDTO{
+				ExternalID:    strconv.FormatInt(anime.ID, 10),
+				RusName:       anime.Russian,
+				EngName:       anime.Name,
+				ImageURL:      anime.Image.Preview,
+				NextEpisodeAt: nextEpisodeAt,
+			}
+			if err := si.adao.Upsert(&animeDTO); err != nil {
+				return err
+			}
+			imported++
+		}
+		if !hasNextPage {
+			break
+		}
+	}
+	log.Printf("Imported %d animes from Shikimori\n", imported)
+	return nil
+}
+
+func (si *ShikimoriImporter) readOngoingsPage(page int) ([]ShikimoriAnime, bool, error) {
+	query := url.Values{}
+	query.Set("status", "ongoing")
+	query.Set("order", "id")
+	query.Set("page", strconv.Itoa(page))
+	query.Set("limit", strconv.Itoa(shikimoriPageLimit))
+	animes := make([]ShikimoriAnime, 0, shikimoriPageLimit+1)
+	if err := si.get("/api/animes?"+query.Encode(), &animes); err != nil {
+		return nil, false, err
+	}
+	log.Printf("Read %d ongoings from page %d\n", len(animes), page)
+	if len(animes) > shikimoriPageLimit {
+		return animes[:shikimoriPageLimit], true, nil
+	}
+	return animes, false, nil
+}
+
+func (si *ShikimoriImporter) readCalendar() (map[int64]time.Time, error) {
+	calendar := make([]ShikimoriCalendarItem, 0)
+	if err := si.get("/api/calendar", &calendar); err != nil {
+		return nil, err
+	}
+	log.Printf("Read %d calendar items\n", len(calendar))
+	nextEpisodes := make(map[int64]time.Time, len(calendar))
+	for _, item := range calendar {
+		if item.NextEpisodeAt != nil {
+			nextEpisodes[item.Anime.ID] = *item.NextEpisodeAt
+		}
+	}
+	return nextEpisodes, nil
+}
+
+func (si *ShikimoriImporter) get(path string, target interface{}) error {
+	request, requestErr := http.NewRequest(http.MethodGet, si.settings.ShikimoriURL+path, nil)
+	if requestErr != nil {
+		return errors.WithStack(requestErr)
+	}
+	request.Header.Set("User-Agent", "anime-app")
+	response, responseErr := si.client.Do(request)
+	if responseErr != nil {
+		return errors.WithStack(responseErr)
+	}
+	defer response.Body.Close()
+	log.Printf("Shikimori responded to %s with status %d\n", request.URL.Path, response.StatusCode)
+	if response.StatusCode != http.StatusOK {
+		return errors.Errorf("Shikimori responded with status %d", response.StatusCode)
+	}
+	if decodeErr := json.NewDecoder(response.Body).Decode(target); decodeErr != nil {
+		return errors.WithStack(decodeErr)
+	}
+	return nil
+}
+
+//ShikimoriAnime struct
+type ShikimoriAnime struct {
+	ID      int64          `json:"id"`
+	Name    string         `json:"name"`
+	Russian string         `json:"russian"`
+	Image   ShikimoriImage `json:"image"`
+}
+
+//ShikimoriImage struct
+type ShikimoriImage struct {
+	Original string `json:"original"`
+	Preview  string `json:"preview"`
+}
+
+//ShikimoriCalendarItem struct
+type ShikimoriCalendarItem struct {
+	NextEpisode   int64          `json:"next_episode"`
+	NextEpisodeAt *time.Time     `json:"next_episode_at"`
+	Anime         ShikimoriAnime `json:"anime"`
+}
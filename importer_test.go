@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HDIOES/anime-app/dao"
+)
+
+type recordingAnimeDAO struct {
+	upserted []dao.AnimeDTO
+}
+
+func (ra *recordingAnimeDAO) Upsert(animeDTO *dao.AnimeDTO) error {
+	ra.upserted = append(ra.upserted, *animeDTO)
+	return nil
+}
+
+//fakeShikimori func starts Shikimori API stand-in which serves count ongoings and calendar of the even ones
+func fakeShikimori(t *testing.T, count int) *ShikimoriImporter {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		items := make([]string, 0)
+		switch r.URL.Path {
+		case "/api/animes":
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			for id := (page-1)*limit + 1; id <= count && id <= page*limit+1; id++ {
+				items = append(items, fmt.Sprintf(`{"id":%d,"name":"Anime %d","russian":"Аниме %d","image":{"preview":"/%d.jpg"}}`, id, id, id, id))
+			}
+		case "/api/calendar":
+			for id := 2; id <= count; id += 2 {
+				items = append(items, fmt.Sprintf(`{"next_episode":2,"next_episode_at":"2026-10-18T12:00:00Z","anime":{"id":%d}}`, id))
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[" + strings.Join(items, ",") + "]"))
+	}))
+	t.Cleanup(server.Close)
+	return &ShikimoriImporter{client: server.Client(), settings: &Settings{ShikimoriURL: server.URL}}
+}
+
+func TestShikimoriImporterReadsOngoingsPages(t *testing.T) {
+	importer := fakeShikimori(t, shikimoriPageLimit+3)
+	animes, hasNextPage, err := importer.readOngoingsPage(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(animes) != shikimoriPageLimit || !hasNextPage {
+		t.Fatalf("first page has %d animes and next page %t, want %d and true", len(animes), hasNextPage, shikimoriPageLimit)
+	}
+	animes, hasNextPage, err = importer.readOngoingsPage(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(animes) != 3 || hasNextPage {
+		t.Fatalf("second page has %d animes and next page %t, want 3 and false", len(animes), hasNextPage)
+	}
+	if anime := animes[0]; anime.ID != shikimoriPageLimit+1 || anime.Russian == "" || anime.Image.Preview == "" {
+		t.Fatalf("anime is decoded as %+v", anime)
+	}
+}
+
+func TestShikimoriImporterReadsCalendar(t *testing.T) {
+	importer := fakeShikimori(t, 5)
+	nextEpisodes, err := importer.readCalendar()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nextEpisodes) != 2 {
+		t.Fatalf("calendar has %d animes, want 2", len(nextEpisodes))
+	}
+	if nextEpisodeAt := nextEpisodes[4]; !nextEpisodeAt.Equal(time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("next episode is at %v", nextEpisodeAt)
+	}
+}
+
+func TestShikimoriImporterFailsOnErrorStatus(t *testing.T) {
+	importer := fakeShikimori(t, 5)
+	if err := importer.get("/api/unknown", &[]ShikimoriAnime{}); err == nil {
+		t.Fatal("request to unknown path succeeded")
+	}
+}
+
+func TestShikimoriImporterUpsertsScheduledOngoings(t *testing.T) {
+	importer := fakeShikimori(t, shikimoriPageLimit+3)
+	adao := &recordingAnimeDAO{}
+	importer.adao = adao
+	if err := importer.importOngoings(); err != nil {
+		t.Fatal(err)
+	}
+	if len(adao.upserted) != (shikimoriPageLimit+3)/2 {
+		t.Fatalf("%d animes upserted, want only %d animes from calendar", len(adao.upserted), (shikimoriPageLimit+3)/2)
+	}
+	for _, animeDTO := range adao.upserted {
+		id, _ := strconv.Atoi(animeDTO.ExternalID)
+		if id%2 != 0 {
+			t.Fatalf("anime %s is not in calendar", animeDTO.ExternalID)
+		}
+		expected := dao.AnimeDTO{
+			ExternalID:    animeDTO.ExternalID,
+			RusName:       fmt.Sprintf("Аниме %d", id),
+			EngName:       fmt.Sprintf("Anime %d", id),
+			ImageURL:      fmt.Sprintf("/%d.jpg", id),
+			NextEpisodeAt: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC),
+		}
+		if !animeDTO.NextEpisodeAt.Equal(expected.NextEpisodeAt) {
+			t.Fatalf("anime %s is scheduled at %v, want %v", animeDTO.ExternalID, animeDTO.NextEpisodeAt, expected.NextEpisodeAt)
+		}
+		animeDTO.NextEpisodeAt = expected.NextEpisodeAt
+		if animeDTO != expected {
+			t.Fatalf("anime is mapped to %+v, want %+v", animeDTO, expected)
+		}
+	}
+}
@@ -1,52 +1,122 @@
 package main
 
 import (
-	"encoding/json"
-	"net/http"
+	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
-	"github.com/nats-io/nats.go"
 	"github.com/pkg/errors"
 
 	"github.com/HDIOES/anime-app/dao"
+	"github.com/HDIOES/anime-app/i18n"
 )
 
 const (
-	welcomeText        = "Данный бот предназначен для своевременного уведомления о выходе в эфир эпизодов ваших любимых аниме-сериалов"
-	alertText          = "С возвращением! Ранее вы уже пользовались ботом, все ваши подписки сохранены"
-	unknownCommandText = "Неизвестная команда"
+	welcomeKey         = "welcome"
+	alertKey           = "alert"
+	resumedKey         = "resumed"
+	unknownCommandKey  = "unknownCommand"
+	notificationKey    = "notification"
+	listKey            = "list"
+	emptyListKey       = "emptyList"
+	unsubscribeAllKey  = "unsubscribeAll"
+	stopKey            = "stop"
+	unsubscribedKey    = "unsubscribed"
+	stoppedKey         = "stopped"
+	cancelledKey       = "cancelled"
+	yesKey             = "yes"
+	noKey              = "no"
+	chooseLanguageKey  = "chooseLanguage"
+	languageChangedKey = "languageChanged"
+	languageNameKey    = "languageName"
+	timezoneKey        = "timezone"
+	timezoneChangedKey = "timezoneChanged"
+	timezoneInvalidKey = "timezoneInvalid"
+	nextEpisodeAtKey   = "nextEpisodeAt"
+	airedAtKey         = "airedAt"
+	reminderKey        = "reminder"
+	remindHourKey      = "remindHour"
+	remindDayKey       = "remindDay"
+	remindOffKey       = "remindOff"
+	reminderSetKey     = "reminderSet"
+	reminderOffKey     = "reminderOff"
+	digestKey          = "digest"
+	digestModeKey      = "digestMode"
+	digestChangedKey   = "digestChanged"
+	digestInvalidKey   = "digestInvalid"
+	instantModeKey     = "instantMode"
+	dailyModeKey       = "dailyMode"
+	weeklyModeKey      = "weeklyMode"
+	settingsKey        = "settings"
+	quietHoursKey      = "quietHours"
+	quietOffKey        = "quietOff"
+	quietChangedKey    = "quietChanged"
+	quietInvalidKey    = "quietInvalid"
+)
+
+//russianLocale is the only locale which shows RUSNAME of animes
+const russianLocale = "ru"
+
+const (
+	subscribeCallback      = "sub"
+	unsubscribeCallback    = "unsub"
+	listCallback           = "list"
+	unsubscribeAllCallback = "unsuball"
+	stopCallback           = "stop"
+	languageCallback       = "lang"
+	reminderCallback       = "remind"
+)
+
+//reminderOptions maps reminder lead time in seconds to text key of its button, zero lead disables reminder
+var reminderOptions = []struct {
+	lead int64
+	key  string
+}{
+	{lead: 3600, key: remindHourKey},
+	{lead: 86400, key: remindDayKey},
+	{lead: 0, key: remindOffKey},
+}
+
+const (
+	privateChatType = "private"
+	kickedStatus    = "kicked"
+	memberStatus    = "member"
 )
 
 const (
-	startType       = "startType"
-	answerQueryType = "answerQueryType"
-	subscribeType   = "subscribeType"
-	unsubscribeType = "unsubscribeType"
-	defaultType     = "defaultType"
+	startType          = "startType"
+	answerQueryType    = "answerQueryType"
+	subscribeType      = "subscribeType"
+	unsubscribeType    = "unsubscribeType"
+	defaultType        = "defaultType"
+	notificationType   = "notificationType"
+	listType           = "listType"
+	confirmationType   = "confirmationType"
+	editTextType       = "editTextType"
+	languageType       = "languageType"
+	reminderType       = "reminderType"
+	answerCallbackType = "answerCallbackType"
+	digestType         = "digestType"
 )
 
-//TelegramHandler struct
+//TelegramHandler struct implements UpdateDispatcher
 type TelegramHandler struct {
-	udao           *dao.UserDAO
-	sdao           *dao.SubscriptionDAO
-	adao           *dao.AnimeDAO
-	natsConnection *nats.Conn
-	settings       *Settings
-}
-
-func (th *TelegramHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	reqReader, logReqErr := logRequest(r)
-	if logReqErr != nil {
-		HandleError(logReqErr)
-		return
-	}
-	decoder := json.NewDecoder(reqReader)
-	update := &Update{}
-	decodeErr := decoder.Decode(update)
-	if decodeErr != nil {
-		HandleError(decodeErr)
-		return
+	udao        *dao.UserDAO
+	sdao        *dao.SubscriptionDAO
+	adao        *dao.AnimeDAO
+	pdao        *dao.PreferencesDAO
+	sender      MessageSender
+	settings    *Settings
+	updateStore UpdateStore
+	catalog     *i18n.Catalog
+	//droppedDuplicates is accessed atomically
+	droppedDuplicates uint64
+}
+
+func (th *TelegramHandler) dispatchUpdate(update *Update) error {
+	if update.MyChatMember != nil {
+		return th.chatMemberUpdated(update.MyChatMember)
 	}
 	isMessage := update.Message != nil
 	isInlineQuery := update.InlineQuery != nil
@@ -54,117 +124,209 @@ func (th *TelegramHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	existedBefore := false
 	var err error
 	var userDTO *dao.UserDTO
+	var from *User
 	if isMessage {
-		userDTO, existedBefore, err = th.checkAndSaveUserIfPossible(&update.Message.From)
+		from = &update.Message.From
 	} else if isInlineQuery {
-		userDTO, existedBefore, err = th.checkAndSaveUserIfPossible(&update.InlineQuery.From)
+		from = &update.InlineQuery.From
 	} else if isCallbackQuery {
-		userDTO, existedBefore, err = th.checkAndSaveUserIfPossible(&update.CallbackQuery.From)
+		from = &update.CallbackQuery.From
+	} else {
+		return nil
+	}
+	userDTO, existedBefore, err = th.checkAndSaveUserIfPossible(from)
+	if err != nil {
+		return newTransientError(err)
 	}
+	locale := th.catalog.Resolve(from.LanguageCode)
+	if userDTO.Locale != "" {
+		locale = userDTO.Locale
+	}
+	timezone := userDTO.Timezone
 	if isMessage {
-		if strings.HasPrefix(update.Message.Text, "/start") {
-			parts := strings.SplitN(update.Message.Text, " ", 2)
-			switch len(parts) {
-			case 1:
-				{
-					err = th.startCommand(update.Message.From.ID, existedBefore)
-				}
-			case 2:
-				{
-					internalAnimeID, parseErr := strconv.ParseInt(parts[1], 10, 64)
-					if parseErr != nil {
-						err = parseErr
-					} else {
-						err = th.startCommandWithInternalAnimeID(update.Message.From.ID, userDTO.ID, internalAnimeID)
-					}
-				}
-			default:
-				{
-					err = errors.New("Parse error")
+		command, argument := splitCommand(update.Message.Text)
+		if command == "/start" {
+			resumed := existedBefore && !userDTO.Active
+			if !userDTO.Active {
+				if err := th.udao.SetActive(userDTO.ID, true); err != nil {
+					return newTransientError(err)
 				}
 			}
-		} else {
-			err = errors.New("Parse error")
+			if argument == "" {
+				return th.startCommand(update.Message.From.ID, locale, existedBefore, resumed)
+			}
+			internalAnimeID, parseErr := strconv.ParseInt(argument, 10, 64)
+			if parseErr != nil {
+				return newClientError(parseErr)
+			}
+			return th.startCommandWithInternalAnimeID(update.Message.From.ID, userDTO.ID, locale, timezone, internalAnimeID)
+		} else if command == "/list" {
+			return th.listCommand(update.Message.From.ID, userDTO.ID, locale, 0, 0, 0, "")
+		} else if command == "/unsubscribe_all" {
+			return th.confirmationCommand(update.Message.From.ID, locale, unsubscribeAllKey, unsubscribeAllCallback)
+		} else if command == "/stop" {
+			return th.confirmationCommand(update.Message.From.ID, locale, stopKey, stopCallback)
+		} else if command == "/language" {
+			return th.languageCommand(update.Message.From.ID, locale)
+		} else if command == "/timezone" {
+			return th.timezoneCommand(update.Message.From.ID, userDTO.ID, locale, timezone, argument)
+		} else if command == "/digest" {
+			return th.digestCommand(update.Message.From.ID, userDTO.ID, locale, strings.Fields(argument))
+		} else if command == "/settings" {
+			return th.settingsCommand(update.Message.From.ID, userDTO.ID, locale, timezone, strings.Fields(argument))
 		}
+		return newClientError(errors.New("Unknown command"))
 	} else if isInlineQuery {
-		err = th.inlineQueryCommand(userDTO.ID, update)
+		return th.inlineQueryCommand(userDTO.ID, locale, update)
 	} else if isCallbackQuery {
 		parts := strings.SplitN(update.CallbackQuery.Data, " ", 2)
 		if len(parts) == 2 && update.CallbackQuery.Message != nil {
 			command := parts[0]
-			internalAnimeID, parseErr := strconv.ParseInt(parts[1], 10, 64)
+			if command == reminderCallback {
+				return th.reminderCommand(
+					userDTO.ID,
+					locale,
+					parts[1],
+					update.CallbackQuery.Message.Chat.ID,
+					update.CallbackQuery.ID)
+			}
+			if command == languageCallback {
+				return th.languageSelectedCommand(
+					userDTO.ID,
+					parts[1],
+					update.CallbackQuery.Message.Chat.ID,
+					update.CallbackQuery.Message.MessageID,
+					update.CallbackQuery.ID)
+			}
+			argument, parseErr := strconv.ParseInt(parts[1], 10, 64)
 			if parseErr != nil {
-				err = errors.WithStack(parseErr)
+				return newClientError(parseErr)
 			}
 			switch command {
-			case "sub":
+			case subscribeCallback:
 				{
-					err = th.subscribeCommand(
+					return th.subscribeCommand(
 						userDTO.ID,
-						internalAnimeID,
+						locale,
+						argument,
 						update.CallbackQuery.Message.Chat.ID,
 						update.CallbackQuery.Message.MessageID,
 						update.CallbackQuery.ID)
 				}
-			case "unsub":
+			case unsubscribeCallback:
 				{
-					err = th.unsubscribeCommand(
+					return th.unsubscribeCommand(
 						userDTO.ID,
-						internalAnimeID,
+						locale,
+						argument,
+						update.CallbackQuery.Message.Chat.ID,
+						update.CallbackQuery.Message.MessageID,
+						update.CallbackQuery.ID)
+				}
+			case listCallback:
+				{
+					if argument < 0 {
+						return newClientError(errors.Errorf("Page %d is negative", argument))
+					}
+					return th.listCommand(
+						update.CallbackQuery.From.ID,
+						userDTO.ID,
+						locale,
+						argument,
+						update.CallbackQuery.Message.Chat.ID,
+						update.CallbackQuery.Message.MessageID,
+						update.CallbackQuery.ID)
+				}
+			case unsubscribeAllCallback:
+				{
+					return th.unsubscribeAllCommand(
+						userDTO.ID,
+						locale,
+						argument == 1,
+						update.CallbackQuery.Message.Chat.ID,
+						update.CallbackQuery.Message.MessageID,
+						update.CallbackQuery.ID)
+				}
+			case stopCallback:
+				{
+					return th.stopCommand(
+						userDTO.ID,
+						locale,
+						argument == 1,
 						update.CallbackQuery.Message.Chat.ID,
 						update.CallbackQuery.Message.MessageID,
 						update.CallbackQuery.ID)
 				}
 			default:
 				{
-					err = errors.New("Parse error")
+					return newClientError(errors.New("Unknown callback command"))
 				}
 			}
-		} else {
-			err = errors.New("Parse error")
 		}
+		return newClientError(errors.New("Parse error"))
 	}
-	if err != nil {
-		HandleError(err)
+	return nil
+}
+
+//splitCommand func splits message text into command and its argument.
+//Command may be addressed to the bot as /command@botname, the suffix is dropped
+func splitCommand(text string) (string, string) {
+	parts := strings.SplitN(strings.TrimSpace(text), " ", 2)
+	command := parts[0]
+	if at := strings.Index(command, "@"); at >= 0 {
+		command = command[:at]
+	}
+	if len(parts) == 1 {
+		return command, ""
 	}
+	return command, strings.TrimSpace(parts[1])
 }
 
 func (th *TelegramHandler) checkAndSaveUserIfPossible(user *User) (userDTO *dao.UserDTO, existedBefore bool, err error) {
 	telegramUserID := strconv.FormatInt(user.ID, 10)
-	telegramUsername := user.Username
-	userDto, findErr := th.udao.Find(telegramUserID)
-	if findErr != nil {
-		return nil, false, findErr
-	}
-	if userDto == nil {
-		if newUserDTO, insertErr := th.udao.Insert(telegramUserID, telegramUsername); insertErr != nil {
-			return newUserDTO, false, insertErr
-		}
-		return nil, false, nil
+	userDto, inserted, upsertErr := th.udao.Upsert(telegramUserID, user.Username, th.catalog.Resolve(user.LanguageCode))
+	if upsertErr != nil {
+		return nil, false, upsertErr
 	}
-	return userDto, true, nil
+	return userDto, !inserted, nil
 }
 
-func (th *TelegramHandler) sendNtsMessage(ntsMessage *TelegramCommandMessage) error {
-	data, dataErr := json.Marshal(ntsMessage)
-	if dataErr != nil {
-		return errors.WithStack(dataErr)
+//chatMemberUpdated func marks the user blocked when the bot is kicked from private chat and unblocked when it is a member again
+func (th *TelegramHandler) chatMemberUpdated(chatMember *ChatMemberUpdated) error {
+	if chatMember.Chat.Type != privateChatType {
+		return nil
+	}
+	var blocked bool
+	switch chatMember.NewChatMember.Status {
+	case kickedStatus:
+		blocked = true
+	case memberStatus:
+		blocked = false
+	default:
+		return nil
 	}
-	if publishErr := th.natsConnection.Publish(th.settings.NatsSubject, data); publishErr != nil {
-		return errors.WithStack(publishErr)
+	if err := th.udao.SetBlocked(strconv.FormatInt(chatMember.From.ID, 10), blocked); err != nil {
+		return newTransientError(err)
 	}
 	return nil
 }
 
-func (th *TelegramHandler) startCommand(userTelegramID int64, existedBefore bool) error {
+func (th *TelegramHandler) sendNtsMessage(ntsMessage *TelegramCommandMessage) error {
+	return th.sender.Send(ntsMessage)
+}
+
+//startCommand greets the user. User resumed after /stop has no subscriptions left, so the alert about kept subscriptions is not shown
+func (th *TelegramHandler) startCommand(userTelegramID int64, locale string, existedBefore, resumed bool) error {
 	ntsMessage := TelegramCommandMessage{
 		TelegramID: userTelegramID,
 		Type:       startType,
 	}
 	if !existedBefore {
-		ntsMessage.Text = welcomeText
+		ntsMessage.Text = th.catalog.Text(locale, welcomeKey)
+	} else if resumed {
+		ntsMessage.Text = th.catalog.Text(locale, resumedKey)
 	} else {
-		ntsMessage.Text = alertText
+		ntsMessage.Text = th.catalog.Text(locale, alertKey)
 	}
 	if err := th.sendNtsMessage(&ntsMessage); err != nil {
 		return err
@@ -172,41 +334,68 @@ func (th *TelegramHandler) startCommand(userTelegramID int64, existedBefore bool
 	return nil
 }
 
-func (th *TelegramHandler) startCommandWithInternalAnimeID(userTelegramID, internalUserID, internalAnimeID int64) error {
+func (th *TelegramHandler) startCommandWithInternalAnimeID(userTelegramID, internalUserID int64, locale, timezone string, internalAnimeID int64) error {
 	ntsMessage := TelegramCommandMessage{
 		TelegramID: userTelegramID,
 		Type:       startType,
 	}
 	userAnimeDto, err := th.adao.FindByUserIDAndInternalID(internalUserID, internalAnimeID)
 	if err != nil {
-		return err
+		return newTransientError(err)
+	}
+	if userAnimeDto == nil {
+		return newClientError(errors.Errorf("Anime %d not found", internalAnimeID))
 	}
 	ntsMessage.InlineAnime = &InlineAnime{
 		InternalID:           internalAnimeID,
-		AnimeName:            userAnimeDto.EngName,
+		AnimeName:            animeName(locale, &userAnimeDto.AnimeDTO),
 		AnimeThumbnailPicURL: th.settings.ShikimoriURL + userAnimeDto.ImageURL,
 		UserHasSubscription:  userAnimeDto.UserHasSubscription,
 	}
+	location, timezoneName := userTimezone(th.settings, timezone)
+	ntsMessage.InlineAnime.AirTime = th.catalog.Format(locale, nextEpisodeAtKey, formatAirTime(userAnimeDto.NextEpisodeAt, location, timezoneName))
+	if userAnimeDto.UserHasSubscription {
+		for _, option := range reminderOptions {
+			ntsMessage.InlineButtons = append(ntsMessage.InlineButtons, InlineButton{
+				Text:         th.catalog.Text(locale, option.key),
+				CallbackData: fmt.Sprintf("%s %d %d", reminderCallback, internalAnimeID, option.lead),
+			})
+		}
+	}
 	if err := th.sendNtsMessage(&ntsMessage); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (th *TelegramHandler) inlineQueryCommand(internalUserID int64, update *Update) error {
-	userAnimes, err := th.adao.ReadUserAnimes(internalUserID, update.InlineQuery.Query)
+func (th *TelegramHandler) inlineQueryCommand(internalUserID int64, locale string, update *Update) error {
+	offset := int64(0)
+	if update.InlineQuery.Offset != "" {
+		parsedOffset, parseErr := strconv.ParseInt(update.InlineQuery.Offset, 10, 64)
+		if parseErr != nil {
+			return newClientError(parseErr)
+		}
+		if parsedOffset < 0 {
+			return newClientError(errors.Errorf("Offset %d is negative", parsedOffset))
+		}
+		offset = parsedOffset
+	}
+	userAnimes, hasNextPage, err := th.adao.SearchUserAnimes(internalUserID, update.InlineQuery.Query, offset)
 	if err != nil {
-		return err
+		return newTransientError(err)
 	}
 	ntsMessage := TelegramCommandMessage{
 		Type:          answerQueryType,
 		InlineQueryID: update.InlineQuery.ID,
 	}
+	if hasNextPage {
+		ntsMessage.NextOffset = strconv.FormatInt(offset+int64(len(userAnimes)), 10)
+	}
 	ntsMessage.InlineAnimes = make([]InlineAnime, 0, len(userAnimes))
 	for _, userAnime := range userAnimes {
 		ntsMessage.InlineAnimes = append(ntsMessage.InlineAnimes, InlineAnime{
 			InternalID:           userAnime.ID,
-			AnimeName:            userAnime.EngName,
+			AnimeName:            animeName(locale, &userAnime.AnimeDTO),
 			AnimeThumbnailPicURL: th.settings.ShikimoriURL + userAnime.ImageURL,
 			UserHasSubscription:  userAnime.UserHasSubscription,
 		})
@@ -217,65 +406,363 @@ func (th *TelegramHandler) inlineQueryCommand(internalUserID int64, update *Upda
 	return nil
 }
 
-func (th *TelegramHandler) subscribeCommand(internalUserID, internalAnimeID int64, chatID, messageID int64, callbackQueryID string) error {
-	found, err := th.sdao.Find(internalUserID, internalAnimeID)
+//listCommand sends page of user subscriptions, chatID, messageID and callbackQueryID are set when page is requested from callback button
+func (th *TelegramHandler) listCommand(userTelegramID, internalUserID int64, locale string, page, chatID, messageID int64, callbackQueryID string) error {
+	animes, hasNextPage, err := th.sdao.ReadUserSubscriptions(internalUserID, page)
 	if err != nil {
+		return newTransientError(err)
+	}
+	ntsMessage := TelegramCommandMessage{
+		Type:            listType,
+		TelegramID:      userTelegramID,
+		Text:            th.catalog.Text(locale, listKey),
+		Page:            page,
+		HasNextPage:     hasNextPage,
+		ChatID:          chatID,
+		MessageID:       messageID,
+		CallbackQueryID: callbackQueryID,
+	}
+	if len(animes) == 0 && page == 0 {
+		ntsMessage.Text = th.catalog.Text(locale, emptyListKey)
+	}
+	ntsMessage.InlineAnimes = make([]InlineAnime, 0, len(animes))
+	for i := range animes {
+		anime := animes[i]
+		ntsMessage.InlineAnimes = append(ntsMessage.InlineAnimes, InlineAnime{
+			InternalID:           anime.ID,
+			AnimeName:            animeName(locale, &anime),
+			AnimeThumbnailPicURL: th.settings.ShikimoriURL + anime.ImageURL,
+			UserHasSubscription:  true,
+			NextEpisodeAt:        &anime.NextEpisodeAt,
+		})
+	}
+	if err := th.sendNtsMessage(&ntsMessage); err != nil {
 		return err
 	}
+	return nil
+}
+
+func (th *TelegramHandler) subscribeCommand(internalUserID int64, locale string, internalAnimeID, chatID, messageID int64, callbackQueryID string) error {
+	found, err := th.sdao.Find(internalUserID, internalAnimeID)
+	if err != nil {
+		return newTransientError(err)
+	}
 	if found {
-		if err := th.defaultCommand(internalUserID); err != nil {
+		if err := th.defaultCommand(chatID, locale); err != nil {
 			return err
 		}
 	} else {
-		if err := th.sdao.Insert(internalUserID, internalAnimeID); err != nil {
-			return err
-		}
-		ntsMessage := TelegramCommandMessage{
+		message, encodeErr := th.sender.Encode(&TelegramCommandMessage{
 			Type:            subscribeType,
 			ChatID:          chatID,
 			MessageID:       messageID,
 			InternalAnimeID: internalAnimeID,
 			CallbackQueryID: callbackQueryID,
+		})
+		if encodeErr != nil {
+			return encodeErr
 		}
-		if err := th.sendNtsMessage(&ntsMessage); err != nil {
-			return err
+		if err := th.sdao.Insert(internalUserID, internalAnimeID, message); err != nil {
+			return newTransientError(err)
 		}
 	}
 	return nil
 }
 
-func (th *TelegramHandler) unsubscribeCommand(internalUserID, internalAnimeID, chatID, messageID int64, callbackQueryID string) error {
+func (th *TelegramHandler) unsubscribeCommand(internalUserID int64, locale string, internalAnimeID, chatID, messageID int64, callbackQueryID string) error {
 	found, err := th.sdao.Find(internalUserID, internalAnimeID)
 	if err != nil {
-		return err
+		return newTransientError(err)
 	}
 	if found {
-		if err := th.sdao.Delete(internalUserID, internalAnimeID); err != nil {
-			return err
-		}
-		ntsMessage := TelegramCommandMessage{
+		message, encodeErr := th.sender.Encode(&TelegramCommandMessage{
 			Type:            unsubscribeType,
 			ChatID:          chatID,
 			MessageID:       messageID,
 			InternalAnimeID: internalAnimeID,
 			CallbackQueryID: callbackQueryID,
+		})
+		if encodeErr != nil {
+			return encodeErr
 		}
-		if err := th.sendNtsMessage(&ntsMessage); err != nil {
-			return err
+		if err := th.sdao.Delete(internalUserID, internalAnimeID, message); err != nil {
+			return newTransientError(err)
 		}
 	} else {
-		if err := th.defaultCommand(internalAnimeID); err != nil {
+		if err := th.defaultCommand(chatID, locale); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (th *TelegramHandler) defaultCommand(userTelegramID int64) error {
+//confirmationCommand asks user to confirm action, answer comes back as "<callbackCommand> 1" or "<callbackCommand> 0" callback
+func (th *TelegramHandler) confirmationCommand(userTelegramID int64, locale, textKey, callbackCommand string) error {
+	ntsMessage := TelegramCommandMessage{
+		Type:       confirmationType,
+		TelegramID: userTelegramID,
+		Text:       th.catalog.Text(locale, textKey),
+		InlineButtons: []InlineButton{
+			{Text: th.catalog.Text(locale, yesKey), CallbackData: callbackCommand + " 1"},
+			{Text: th.catalog.Text(locale, noKey), CallbackData: callbackCommand + " 0"},
+		},
+	}
+	if err := th.sendNtsMessage(&ntsMessage); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (th *TelegramHandler) unsubscribeAllCommand(internalUserID int64, locale string, confirmed bool, chatID, messageID int64, callbackQueryID string) error {
+	if !confirmed {
+		return th.editTextCommand(chatID, messageID, callbackQueryID, th.catalog.Text(locale, cancelledKey))
+	}
+	message, encodeErr := th.sender.Encode(editTextMessage(chatID, messageID, callbackQueryID, th.catalog.Text(locale, unsubscribedKey)))
+	if encodeErr != nil {
+		return encodeErr
+	}
+	if err := th.sdao.DeleteAll(internalUserID, message); err != nil {
+		return newTransientError(err)
+	}
+	return nil
+}
+
+func (th *TelegramHandler) stopCommand(internalUserID int64, locale string, confirmed bool, chatID, messageID int64, callbackQueryID string) error {
+	if !confirmed {
+		return th.editTextCommand(chatID, messageID, callbackQueryID, th.catalog.Text(locale, cancelledKey))
+	}
+	message, encodeErr := th.sender.Encode(editTextMessage(chatID, messageID, callbackQueryID, th.catalog.Text(locale, stoppedKey)))
+	if encodeErr != nil {
+		return encodeErr
+	}
+	if err := th.udao.Deactivate(internalUserID, message); err != nil {
+		return newTransientError(err)
+	}
+	return nil
+}
+
+//editTextCommand replaces text of the message with inline keyboard and answers its callback query
+func (th *TelegramHandler) editTextCommand(chatID, messageID int64, callbackQueryID, text string) error {
+	if err := th.sendNtsMessage(editTextMessage(chatID, messageID, callbackQueryID, text)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func editTextMessage(chatID, messageID int64, callbackQueryID, text string) *TelegramCommandMessage {
+	return &TelegramCommandMessage{
+		Type:            editTextType,
+		Text:            text,
+		ChatID:          chatID,
+		MessageID:       messageID,
+		CallbackQueryID: callbackQueryID,
+	}
+}
+
+//reminderCommand sets reminder lead time for subscription, argument is "<internalAnimeID> <lead in seconds>"
+func (th *TelegramHandler) reminderCommand(internalUserID int64, locale, argument string, chatID int64, callbackQueryID string) error {
+	var internalAnimeID, lead int64
+	if _, scanErr := fmt.Sscanf(argument, "%d %d", &internalAnimeID, &lead); scanErr != nil {
+		return newClientError(scanErr)
+	}
+	supported := false
+	for _, option := range reminderOptions {
+		supported = supported || option.lead == lead
+	}
+	if !supported {
+		return newClientError(errors.Errorf("Reminder lead %d is not supported", lead))
+	}
+	found, err := th.sdao.SetReminder(internalUserID, internalAnimeID, lead)
+	if err != nil {
+		return newTransientError(err)
+	}
+	if !found {
+		return th.defaultCommand(chatID, locale)
+	}
+	ntsMessage := TelegramCommandMessage{
+		Type:            answerCallbackType,
+		CallbackQueryID: callbackQueryID,
+		Text:            th.catalog.Text(locale, reminderSetKey),
+	}
+	if lead == 0 {
+		ntsMessage.Text = th.catalog.Text(locale, reminderOffKey)
+	}
+	if err := th.sendNtsMessage(&ntsMessage); err != nil {
+		return err
+	}
+	return nil
+}
+
+//languageCommand shows inline keyboard with every supported locale
+func (th *TelegramHandler) languageCommand(userTelegramID int64, locale string) error {
+	ntsMessage := TelegramCommandMessage{
+		Type:       languageType,
+		TelegramID: userTelegramID,
+		Text:       th.catalog.Text(locale, chooseLanguageKey),
+	}
+	for _, supportedLocale := range th.catalog.Locales() {
+		ntsMessage.InlineButtons = append(ntsMessage.InlineButtons, InlineButton{
+			Text:         th.catalog.Text(supportedLocale, languageNameKey),
+			CallbackData: languageCallback + " " + supportedLocale,
+		})
+	}
+	if err := th.sendNtsMessage(&ntsMessage); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (th *TelegramHandler) languageSelectedCommand(internalUserID int64, locale string, chatID, messageID int64, callbackQueryID string) error {
+	if th.catalog.Resolve(locale) != locale {
+		return newClientError(errors.Errorf("Locale %s is not supported", locale))
+	}
+	if err := th.udao.SetLocale(internalUserID, locale); err != nil {
+		return newTransientError(err)
+	}
+	return th.editTextCommand(chatID, messageID, callbackQueryID, th.catalog.Text(locale, languageChangedKey))
+}
+
+//timezoneCommand shows current timezone of the user when value is empty and changes it otherwise
+func (th *TelegramHandler) timezoneCommand(userTelegramID, internalUserID int64, locale, timezone, value string) error {
+	ntsMessage := TelegramCommandMessage{
+		Type:       defaultType,
+		TelegramID: userTelegramID,
+	}
+	if value == "" {
+		_, timezoneName := userTimezone(th.settings, timezone)
+		ntsMessage.Text = th.catalog.Format(locale, timezoneKey, timezoneName)
+	} else if _, timezoneName, loadErr := loadTimezone(value); loadErr != nil {
+		ntsMessage.Text = th.catalog.Format(locale, timezoneInvalidKey, value)
+	} else {
+		if err := th.udao.SetTimezone(internalUserID, timezoneName); err != nil {
+			return newTransientError(err)
+		}
+		ntsMessage.Text = th.catalog.Format(locale, timezoneChangedKey, timezoneName)
+	}
+	if err := th.sendNtsMessage(&ntsMessage); err != nil {
+		return err
+	}
+	return nil
+}
+
+//digestCommand shows notification mode of the user when args are empty and changes it otherwise.
+//Accepted args are "instant", "daily <hour>" and "weekly <hour>"
+func (th *TelegramHandler) digestCommand(userTelegramID, internalUserID int64, locale string, args []string) error {
+	ntsMessage := TelegramCommandMessage{
+		Type:       defaultType,
+		TelegramID: userTelegramID,
+	}
+	if len(args) == 0 {
+		preferencesDTO, err := th.pdao.Find(internalUserID)
+		if err != nil {
+			return newTransientError(err)
+		}
+		ntsMessage.Text = th.catalog.Format(locale, digestModeKey, th.notificationModeText(locale, preferencesDTO.NotificationMode, preferencesDTO.DigestHour))
+	} else if mode, digestHour, ok := parseNotificationMode(args); !ok {
+		ntsMessage.Text = th.catalog.Text(locale, digestInvalidKey)
+	} else {
+		if err := th.pdao.SetNotificationMode(internalUserID, mode, digestHour); err != nil {
+			return newTransientError(err)
+		}
+		ntsMessage.Text = th.catalog.Format(locale, digestChangedKey, th.notificationModeText(locale, mode, digestHour))
+	}
+	if err := th.sendNtsMessage(&ntsMessage); err != nil {
+		return err
+	}
+	return nil
+}
+
+func parseNotificationMode(args []string) (string, int, bool) {
+	switch {
+	case len(args) == 1 && args[0] == dao.InstantMode:
+		return dao.InstantMode, 0, true
+	case len(args) == 2 && (args[0] == dao.DailyMode || args[0] == dao.WeeklyMode):
+		digestHour, err := strconv.Atoi(args[1])
+		if err != nil || digestHour < 0 || digestHour > 23 {
+			return "", 0, false
+		}
+		return args[0], digestHour, true
+	}
+	return "", 0, false
+}
+
+func (th *TelegramHandler) notificationModeText(locale, mode string, digestHour int) string {
+	switch mode {
+	case dao.DailyMode:
+		return th.catalog.Format(locale, dailyModeKey, digestHour)
+	case dao.WeeklyMode:
+		return th.catalog.Format(locale, weeklyModeKey, digestHour)
+	}
+	return th.catalog.Text(locale, instantModeKey)
+}
+
+//settingsCommand shows all settings of the user when args are empty.
+//Quiet hours are changed with "quiet <from> <to>" and disabled with "quiet off"
+func (th *TelegramHandler) settingsCommand(userTelegramID, internalUserID int64, locale, timezone string, args []string) error {
+	ntsMessage := TelegramCommandMessage{
+		Type:       defaultType,
+		TelegramID: userTelegramID,
+	}
+	if len(args) == 0 {
+		preferencesDTO, err := th.pdao.Find(internalUserID)
+		if err != nil {
+			return newTransientError(err)
+		}
+		_, timezoneName := userTimezone(th.settings, timezone)
+		ntsMessage.Text = th.catalog.Format(locale, settingsKey,
+			th.catalog.Text(locale, languageNameKey),
+			timezoneName,
+			th.notificationModeText(locale, preferencesDTO.NotificationMode, preferencesDTO.DigestHour),
+			th.quietHoursText(locale, preferencesDTO.QuietFrom, preferencesDTO.QuietTo))
+	} else if quietFrom, quietTo, ok := parseQuietHours(args); !ok {
+		ntsMessage.Text = th.catalog.Text(locale, quietInvalidKey)
+	} else {
+		if err := th.pdao.SetQuietHours(internalUserID, quietFrom, quietTo); err != nil {
+			return newTransientError(err)
+		}
+		ntsMessage.Text = th.catalog.Format(locale, quietChangedKey, th.quietHoursText(locale, quietFrom, quietTo))
+	}
+	if err := th.sendNtsMessage(&ntsMessage); err != nil {
+		return err
+	}
+	return nil
+}
+
+func parseQuietHours(args []string) (int, int, bool) {
+	if len(args) == 2 && args[0] == "quiet" && args[1] == "off" {
+		return 0, 0, true
+	}
+	if len(args) != 3 || args[0] != "quiet" {
+		return 0, 0, false
+	}
+	quietFrom, fromErr := strconv.Atoi(args[1])
+	quietTo, toErr := strconv.Atoi(args[2])
+	if fromErr != nil || toErr != nil || quietFrom < 0 || quietFrom > 23 || quietTo < 0 || quietTo > 23 {
+		return 0, 0, false
+	}
+	return quietFrom, quietTo, true
+}
+
+func (th *TelegramHandler) quietHoursText(locale string, quietFrom, quietTo int) string {
+	if quietFrom == quietTo {
+		return th.catalog.Text(locale, quietOffKey)
+	}
+	return th.catalog.Format(locale, quietHoursKey, quietFrom, quietTo)
+}
+
+//animeName func returns RUSNAME for russian locale and ENGNAME for the others
+func animeName(locale string, anime *dao.AnimeDTO) string {
+	if locale == russianLocale && anime.RusName != "" {
+		return anime.RusName
+	}
+	return anime.EngName
+}
+
+func (th *TelegramHandler) defaultCommand(userTelegramID int64, locale string) error {
 	nstMessage := TelegramCommandMessage{
 		TelegramID: userTelegramID,
 		Type:       defaultType,
-		Text:       unknownCommandText,
+		Text:       th.catalog.Text(locale, unknownCommandKey),
 	}
 	if sendNstMessageErr := th.sendNtsMessage(&nstMessage); sendNstMessageErr != nil {
 		return sendNstMessageErr
@@ -285,10 +772,11 @@ func (th *TelegramHandler) defaultCommand(userTelegramID int64) error {
 
 //Update struct
 type Update struct {
-	UpdateID      int64          `json:"update_id"`
-	Message       *Message       `json:"message"`
-	InlineQuery   *InlineQuery   `json:"inline_query"`
-	CallbackQuery *CallbackQuery `json:"callback_query"`
+	UpdateID      int64              `json:"update_id"`
+	Message       *Message           `json:"message"`
+	InlineQuery   *InlineQuery       `json:"inline_query"`
+	CallbackQuery *CallbackQuery     `json:"callback_query"`
+	MyChatMember  *ChatMemberUpdated `json:"my_chat_member"`
 }
 
 //Message struct
@@ -327,7 +815,23 @@ type User struct {
 
 //Chat struct
 type Chat struct {
-	ID int64 `json:"id"`
+	ID   int64  `json:"id"`
+	Type string `json:"type"`
+}
+
+//ChatMemberUpdated struct is sent when status of the bot in a chat changes, e.g. the user blocks the bot
+type ChatMemberUpdated struct {
+	Chat          Chat       `json:"chat"`
+	From          User       `json:"from"`
+	Date          int64      `json:"date"`
+	OldChatMember ChatMember `json:"old_chat_member"`
+	NewChatMember ChatMember `json:"new_chat_member"`
+}
+
+//ChatMember struct
+type ChatMember struct {
+	User   User   `json:"user"`
+	Status string `json:"status"`
 }
 
 //TelegramCommandMessage struct
@@ -340,17 +844,31 @@ type TelegramCommandMessage struct {
 	//inline query fields
 	InlineQueryID string        `json:"inlineQueryId"`
 	InlineAnimes  []InlineAnime `json:"inlineAnimes"`
+	NextOffset    string        `json:"nextOffset"`
 	//fields for subscribe/unsubscribe action
 	ChatID          int64  `json:"chatId"`
 	MessageID       int64  `json:"messageId"`
 	CallbackQueryID string `json:"callback_query_id"`
 	InternalAnimeID int64  `json:"internal_anime_id"`
+	//fields for messages with inline keyboard
+	InlineButtons []InlineButton `json:"inlineButtons"`
+	//fields for /list, page numbers start from 0
+	Page        int64 `json:"page"`
+	HasNextPage bool  `json:"hasNextPage"`
+}
+
+//InlineButton struct
+type InlineButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callbackData"`
 }
 
 //InlineAnime struct
 type InlineAnime struct {
-	InternalID           int64  `json:"id"`
-	AnimeName            string `json:"animeName"`
-	AnimeThumbnailPicURL string `json:"animeThumbNailPicUrl"`
-	UserHasSubscription  bool   `json:"userHasSubscription"`
+	InternalID           int64      `json:"id"`
+	AnimeName            string     `json:"animeName"`
+	AnimeThumbnailPicURL string     `json:"animeThumbNailPicUrl"`
+	UserHasSubscription  bool       `json:"userHasSubscription"`
+	NextEpisodeAt        *time.Time `json:"nextEpisodeAt"`
+	AirTime              string     `json:"airTime"`
 }
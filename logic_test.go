@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		text     string
+		command  string
+		argument string
+	}{
+		{text: "/list", command: "/list"},
+		{text: "/listen", command: "/listen"},
+		{text: "/list@anime_bot", command: "/list"},
+		{text: "/start 42", command: "/start", argument: "42"},
+		{text: "/start@anime_bot 42", command: "/start", argument: "42"},
+		{text: " /timezone  Europe/London ", command: "/timezone", argument: "Europe/London"},
+		{text: "/digest daily 9", command: "/digest", argument: "daily 9"},
+	}
+	for _, test := range tests {
+		command, argument := splitCommand(test.text)
+		if command != test.command || argument != test.argument {
+			t.Errorf("%q is split into %q and %q, want %q and %q", test.text, command, argument, test.command, test.argument)
+		}
+	}
+}
+
+func TestParseQuietHours(t *testing.T) {
+	tests := []struct {
+		args      []string
+		quietFrom int
+		quietTo   int
+		ok        bool
+	}{
+		{args: []string{"quiet", "23", "7"}, quietFrom: 23, quietTo: 7, ok: true},
+		{args: []string{"quiet", "1", "5"}, quietFrom: 1, quietTo: 5, ok: true},
+		{args: []string{"quiet", "0", "23"}, quietFrom: 0, quietTo: 23, ok: true},
+		{args: []string{"quiet", "8", "8"}, quietFrom: 8, quietTo: 8, ok: true},
+		{args: []string{"quiet", "off"}, ok: true},
+		{args: []string{"quiet", "24", "7"}},
+		{args: []string{"quiet", "23", "24"}},
+		{args: []string{"quiet", "-1", "7"}},
+		{args: []string{"quiet", "23", "-1"}},
+		{args: []string{"quiet", "night", "7"}},
+		{args: []string{"quiet", "23"}},
+		{args: []string{"quiet", "23", "7", "8"}},
+		{args: []string{"daily", "23", "7"}},
+	}
+	for _, test := range tests {
+		quietFrom, quietTo, ok := parseQuietHours(test.args)
+		if quietFrom != test.quietFrom || quietTo != test.quietTo || ok != test.ok {
+			t.Errorf("%q is parsed into %d, %d, %t, want %d, %d, %t", test.args, quietFrom, quietTo, ok, test.quietFrom, test.quietTo, test.ok)
+		}
+	}
+}
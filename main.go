@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/HDIOES/anime-app/dao"
+	"github.com/HDIOES/anime-app/i18n"
 	"github.com/nats-io/nats.go"
 	"github.com/pkg/errors"
 	migrate "github.com/rubenv/sql-migrate"
@@ -28,6 +29,33 @@ const (
 	natsURLEnvName                   = "NATS_URL"
 	natsSubjectEnvName               = "NATS_SUBJECT"
 	shikimoriURLEnvName              = "SHIKIMORI_URL"
+	notificationIntervalEnvName      = "NOTIFICATION_INTERVAL"
+	importIntervalEnvName            = "IMPORT_INTERVAL"
+	webhookSecretEnvName             = "WEBHOOK_SECRET"
+	receiveModeEnvName               = "RECEIVE_MODE"
+	telegramURLEnvName               = "TELEGRAM_URL"
+	botTokenEnvName                  = "BOT_TOKEN"
+	pollingTimeoutEnvName            = "POLLING_TIMEOUT"
+	updateStoreEnvName               = "UPDATE_STORE"
+	updateTTLEnvName                 = "UPDATE_TTL"
+	localesPathEnvName               = "LOCALES_PATH"
+	defaultLocaleEnvName             = "DEFAULT_LOCALE"
+	defaultTimezoneEnvName           = "DEFAULT_TIMEZONE"
+	outboxIntervalEnvName            = "OUTBOX_INTERVAL"
+	jetStreamEnvName                 = "JET_STREAM"
+	jetStreamNameEnvName             = "JET_STREAM_NAME"
+	jetStreamRetriesEnvName          = "JET_STREAM_RETRIES"
+	jetStreamBackoffEnvName          = "JET_STREAM_BACKOFF"
+	inboundSubjectEnvName            = "INBOUND_SUBJECT"
+	inboundQueueEnvName              = "INBOUND_QUEUE"
+	messageContractEnvName           = "MESSAGE_CONTRACT"
+	messageEncodingEnvName           = "MESSAGE_ENCODING"
+	senderModeEnvName                = "SENDER_MODE"
+	botUsernameEnvName               = "BOT_USERNAME"
+	globalRateLimitEnvName           = "GLOBAL_RATE_LIMIT"
+	chatRateLimitEnvName             = "CHAT_RATE_LIMIT"
+	deliveryDeadlineEnvName          = "DELIVERY_DEADLINE"
+	rateLimitStoreEnvName            = "RATE_LIMIT_STORE"
 )
 
 func main() {
@@ -43,12 +71,13 @@ func main() {
 				log.Panicln(decodeErr)
 			} else {
 				setSettingsFromEnv(settings)
+				validateIntervals(settings)
 				return settings
 			}
 		}
 		panic("Unreachable code")
 	})
-	container.Provide(func(settings *Settings) (*sql.DB, *nats.Conn, *dao.AnimeDAO, *dao.UserDAO, *dao.SubscriptionDAO) {
+	container.Provide(func(settings *Settings) (*sql.DB, *nats.Conn, *dao.AnimeDAO, *dao.UserDAO, *dao.SubscriptionDAO, *dao.PreferencesDAO, *dao.OutboxDAO) {
 		db, err := sql.Open("postgres", settings.DatabaseURL)
 		if err != nil {
 			log.Panicln(err)
@@ -68,26 +97,140 @@ func main() {
 		} else {
 			log.Printf("Applied %d migrations!\n", n)
 		}
-		natsConnection, ncErr := nats.Connect(settings.NatsURL)
-		if ncErr != nil {
-			log.Panicln(ncErr)
+		//NATS is not needed when messages are sent with Bot API and updates are not consumed from NATS
+		var natsConnection *nats.Conn
+		if settings.SenderMode != telegramSenderMode || settings.InboundSubject != "" {
+			var ncErr error
+			if natsConnection, ncErr = nats.Connect(settings.NatsURL); ncErr != nil {
+				log.Panicln(ncErr)
+			}
 		}
-		return db, natsConnection, &dao.AnimeDAO{Db: db}, &dao.UserDAO{Db: db}, &dao.SubscriptionDAO{Db: db}
+		return db, natsConnection, &dao.AnimeDAO{Db: db}, &dao.UserDAO{Db: db}, &dao.SubscriptionDAO{Db: db}, &dao.PreferencesDAO{Db: db}, &dao.OutboxDAO{Db: db}
+	})
+	container.Provide(func(settings *Settings, db *sql.DB) UpdateStore {
+		return newUpdateStore(settings, &dao.UpdateDAO{Db: db})
 	})
-	container.Invoke(func(settings *Settings, natsConnection *nats.Conn, adao *dao.AnimeDAO, udao *dao.UserDAO, sdao *dao.SubscriptionDAO) {
-		defer natsConnection.Close()
+	container.Provide(func(settings *Settings, db *sql.DB, natsConnection *nats.Conn, odao *dao.OutboxDAO) MessageSender {
+		rldao := &dao.RateLimitDAO{Db: db}
+		switch settings.MessageContract {
+		case legacyContract, envelopeContract, "":
+		default:
+			log.Panicln("Unknown message contract: ", settings.MessageContract)
+		}
+		switch settings.MessageEncoding {
+		case jsonEncoding, protobufEncoding, "":
+		default:
+			log.Panicln("Unknown message encoding: ", settings.MessageEncoding)
+		}
+		switch settings.SenderMode {
+		case natsSenderMode, "":
+			publisher, publisherErr := NewNatsPublisher(natsConnection, settings)
+			if publisherErr != nil {
+				log.Panicln(publisherErr)
+			}
+			return rateLimited(&NatsSender{publisher: publisher, settings: settings}, odao, rldao, settings)
+		case telegramSenderMode:
+			return rateLimited(&BotAPISender{client: &http.Client{Timeout: time.Minute}, settings: settings}, odao, rldao, settings)
+		}
+		log.Panicln("Unknown sender mode: ", settings.SenderMode)
+		panic("Unreachable code")
+	})
+	container.Provide(func(settings *Settings) *i18n.Catalog {
+		catalog, err := i18n.Load(settings.LocalesPath, settings.DefaultLocale)
+		if err != nil {
+			log.Panicln(err)
+		}
+		return catalog
+	})
+	container.Invoke(func(settings *Settings, natsConnection *nats.Conn, adao *dao.AnimeDAO, udao *dao.UserDAO, sdao *dao.SubscriptionDAO, pdao *dao.PreferencesDAO, odao *dao.OutboxDAO, updateStore UpdateStore, sender MessageSender, catalog *i18n.Catalog) {
+		if natsConnection != nil {
+			defer natsConnection.Close()
+		}
 		handler := &TelegramHandler{
-			udao:           udao,
-			sdao:           sdao,
-			adao:           adao,
-			natsConnection: natsConnection,
-			settings:       settings,
-		}
-		srv := &http.Server{Addr: ":" + strconv.Itoa(settings.ApplicationPort), Handler: handler}
-		log.Fatal(srv.ListenAndServe())
+			udao:        udao,
+			sdao:        sdao,
+			adao:        adao,
+			pdao:        pdao,
+			sender:      sender,
+			settings:    settings,
+			updateStore: updateStore,
+			catalog:     catalog,
+		}
+		digests := &DigestBuilder{
+			pdao:     pdao,
+			sender:   sender,
+			settings: settings,
+			catalog:  catalog,
+		}
+		notifier := &EpisodeNotifier{
+			adao:     adao,
+			sdao:     sdao,
+			pdao:     pdao,
+			digests:  digests,
+			sender:   sender,
+			settings: settings,
+			catalog:  catalog,
+		}
+		go notifier.Run()
+		importer := &ShikimoriImporter{
+			adao:     adao,
+			client:   &http.Client{Timeout: time.Minute},
+			settings: settings,
+		}
+		go importer.Run()
+		relay := &OutboxRelay{
+			odao:     odao,
+			udao:     udao,
+			sender:   sender,
+			settings: settings,
+		}
+		go relay.Run()
+		if settings.InboundSubject != "" {
+			subscriber := &UpdatesSubscriber{
+				dispatcher:     handler,
+				natsConnection: natsConnection,
+				settings:       settings,
+			}
+			if _, subscribeErr := subscriber.Subscribe(); subscribeErr != nil {
+				log.Panicln(subscribeErr)
+			}
+		}
+		switch settings.ReceiveMode {
+		case pollingReceiveMode:
+			{
+				poller := &UpdatesPoller{
+					dispatcher: handler,
+					client:     &http.Client{Timeout: time.Duration(settings.PollingTimeout)*time.Second + time.Minute},
+					settings:   settings,
+				}
+				poller.Run()
+			}
+		case webhookReceiveMode, "":
+			{
+				srv := &http.Server{Addr: ":" + strconv.Itoa(settings.ApplicationPort), Handler: &WebhookHandler{dispatcher: handler, settings: settings}}
+				log.Fatal(srv.ListenAndServe())
+			}
+		default:
+			{
+				log.Panicln("Unknown receive mode: ", settings.ReceiveMode)
+			}
+		}
 	})
 }
 
+//validateIntervals func rejects intervals of background jobs, time.NewTicker panics on non-positive duration
+func validateIntervals(settings *Settings) {
+	if settings.NotificationInterval <= 0 {
+		log.Panicln("Notification interval must be positive: ", settings.NotificationInterval)
+	}
+	if settings.ImportInterval <= 0 {
+		log.Panicln("Import interval must be positive: ", settings.ImportInterval)
+	}
+	if settings.OutboxInterval <= 0 {
+		log.Panicln("Outbox interval must be positive: ", settings.OutboxInterval)
+	}
+}
+
 func setSettingsFromEnv(settings *Settings) {
 	if value := os.Getenv(databaseURLEnvName); value != "" {
 		settings.DatabaseURL = value
@@ -132,19 +275,171 @@ func setSettingsFromEnv(settings *Settings) {
 	if value := os.Getenv(shikimoriURLEnvName); value != "" {
 		settings.ShikimoriURL = value
 	}
+	if value := os.Getenv(notificationIntervalEnvName); value != "" {
+		if intValue, err := strconv.Atoi(value); err != nil {
+			log.Panicln(err)
+		} else {
+			settings.NotificationInterval = intValue
+		}
+	}
+	if value := os.Getenv(importIntervalEnvName); value != "" {
+		if intValue, err := strconv.Atoi(value); err != nil {
+			log.Panicln(err)
+		} else {
+			settings.ImportInterval = intValue
+		}
+	}
+	if value := os.Getenv(webhookSecretEnvName); value != "" {
+		settings.WebhookSecret = value
+	}
+	if value := os.Getenv(receiveModeEnvName); value != "" {
+		settings.ReceiveMode = value
+	}
+	if value := os.Getenv(telegramURLEnvName); value != "" {
+		settings.TelegramURL = value
+	}
+	if value := os.Getenv(botTokenEnvName); value != "" {
+		settings.BotToken = value
+	}
+	if value := os.Getenv(pollingTimeoutEnvName); value != "" {
+		if intValue, err := strconv.Atoi(value); err != nil {
+			log.Panicln(err)
+		} else {
+			settings.PollingTimeout = intValue
+		}
+	}
+	if value := os.Getenv(updateStoreEnvName); value != "" {
+		settings.UpdateStore = value
+	}
+	if value := os.Getenv(updateTTLEnvName); value != "" {
+		if intValue, err := strconv.Atoi(value); err != nil {
+			log.Panicln(err)
+		} else {
+			settings.UpdateTTL = intValue
+		}
+	}
+	if value := os.Getenv(localesPathEnvName); value != "" {
+		settings.LocalesPath = value
+	}
+	if value := os.Getenv(defaultLocaleEnvName); value != "" {
+		settings.DefaultLocale = value
+	}
+	if value := os.Getenv(defaultTimezoneEnvName); value != "" {
+		settings.DefaultTimezone = value
+	}
+	if value := os.Getenv(outboxIntervalEnvName); value != "" {
+		if intValue, err := strconv.Atoi(value); err != nil {
+			log.Panicln(err)
+		} else {
+			settings.OutboxInterval = intValue
+		}
+	}
+	if value := os.Getenv(jetStreamEnvName); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err != nil {
+			log.Panicln(err)
+		} else {
+			settings.JetStream = boolValue
+		}
+	}
+	if value := os.Getenv(jetStreamNameEnvName); value != "" {
+		settings.JetStreamName = value
+	}
+	if value := os.Getenv(jetStreamRetriesEnvName); value != "" {
+		if intValue, err := strconv.Atoi(value); err != nil {
+			log.Panicln(err)
+		} else {
+			settings.JetStreamRetries = intValue
+		}
+	}
+	if value := os.Getenv(jetStreamBackoffEnvName); value != "" {
+		if intValue, err := strconv.Atoi(value); err != nil {
+			log.Panicln(err)
+		} else {
+			settings.JetStreamBackoff = intValue
+		}
+	}
+	if value := os.Getenv(inboundSubjectEnvName); value != "" {
+		settings.InboundSubject = value
+	}
+	if value := os.Getenv(inboundQueueEnvName); value != "" {
+		settings.InboundQueue = value
+	}
+	if value := os.Getenv(messageContractEnvName); value != "" {
+		settings.MessageContract = value
+	}
+	if value := os.Getenv(messageEncodingEnvName); value != "" {
+		settings.MessageEncoding = value
+	}
+	if value := os.Getenv(senderModeEnvName); value != "" {
+		settings.SenderMode = value
+	}
+	if value := os.Getenv(botUsernameEnvName); value != "" {
+		settings.BotUsername = value
+	}
+	if value := os.Getenv(globalRateLimitEnvName); value != "" {
+		if intValue, err := strconv.Atoi(value); err != nil {
+			log.Panicln(err)
+		} else {
+			settings.GlobalRateLimit = intValue
+		}
+	}
+	if value := os.Getenv(chatRateLimitEnvName); value != "" {
+		if intValue, err := strconv.Atoi(value); err != nil {
+			log.Panicln(err)
+		} else {
+			settings.ChatRateLimit = intValue
+		}
+	}
+	if value := os.Getenv(deliveryDeadlineEnvName); value != "" {
+		if intValue, err := strconv.Atoi(value); err != nil {
+			log.Panicln(err)
+		} else {
+			settings.DeliveryDeadline = intValue
+		}
+	}
+	if value := os.Getenv(rateLimitStoreEnvName); value != "" {
+		settings.RateLimitStore = value
+	}
 }
 
 //Settings mapping object for settings.json
 type Settings struct {
-	DatabaseURL        string `json:"databaseUrl"`
-	MaxOpenConnections int    `json:"maxOpenConnections"`
-	MaxIdleConnections int    `json:"maxIdleConnections"`
-	ConnectionTimeout  int    `json:"connectionTimeout"`
-	ApplicationPort    int    `json:"port"`
-	MigrationPath      string `json:"migrationPath"`
-	NatsURL            string `json:"natsUrl"`
-	NatsSubject        string `json:"natsSubject"`
-	ShikimoriURL       string `json:"shikimoriUrl"`
+	DatabaseURL          string `json:"databaseUrl"`
+	MaxOpenConnections   int    `json:"maxOpenConnections"`
+	MaxIdleConnections   int    `json:"maxIdleConnections"`
+	ConnectionTimeout    int    `json:"connectionTimeout"`
+	ApplicationPort      int    `json:"port"`
+	MigrationPath        string `json:"migrationPath"`
+	NatsURL              string `json:"natsUrl"`
+	NatsSubject          string `json:"natsSubject"`
+	ShikimoriURL         string `json:"shikimoriUrl"`
+	NotificationInterval int    `json:"notificationInterval"`
+	ImportInterval       int    `json:"importInterval"`
+	WebhookSecret        string `json:"webhookSecret"`
+	ReceiveMode          string `json:"receiveMode"`
+	TelegramURL          string `json:"telegramUrl"`
+	BotToken             string `json:"botToken"`
+	PollingTimeout       int    `json:"pollingTimeout"`
+	UpdateStore          string `json:"updateStore"`
+	UpdateTTL            int    `json:"updateTtl"`
+	LocalesPath          string `json:"localesPath"`
+	DefaultLocale        string `json:"defaultLocale"`
+	DefaultTimezone      string `json:"defaultTimezone"`
+	OutboxInterval       int    `json:"outboxInterval"`
+	JetStream            bool   `json:"jetStream"`
+	JetStreamName        string `json:"jetStreamName"`
+	JetStreamRetries     int    `json:"jetStreamRetries"`
+	JetStreamBackoff     int    `json:"jetStreamBackoff"`
+	InboundSubject       string `json:"inboundSubject"`
+	InboundQueue         string `json:"inboundQueue"`
+	MessageContract      string `json:"messageContract"`
+	MessageEncoding      string `json:"messageEncoding"`
+	SenderMode           string `json:"senderMode"`
+	BotUsername          string `json:"botUsername"`
+	GlobalRateLimit      int    `json:"globalRateLimit"`
+	ChatRateLimit        int    `json:"chatRateLimit"`
+	DeliveryDeadline     int    `json:"deliveryDeadline"`
+	RateLimitStore       string `json:"rateLimitStore"`
 }
 
 //StackTracer struct
@@ -155,10 +450,11 @@ type StackTracer interface {
 //HandleError func
 func HandleError(handledErr error) {
 	if err, ok := handledErr.(StackTracer); ok {
+		log.Println("Error: ", handledErr)
 		for _, f := range err.StackTrace() {
 			log.Printf("%+s:%d\n", f, f)
 		}
 	} else {
-		log.Println("Unknown error: ", err)
+		log.Println("Unknown error: ", handledErr)
 	}
 }
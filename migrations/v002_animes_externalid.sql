@@ -0,0 +1,14 @@
+
+-- +migrate Up
+CREATE TEMPORARY TABLE DUPLICATE_ANIMES ON COMMIT DROP AS
+    SELECT A.ID, KEPT.ID AS KEPT_ID FROM ANIMES AS A
+    JOIN (SELECT EXTERNALID, MIN(ID) AS ID FROM ANIMES GROUP BY EXTERNALID) AS KEPT
+    ON (A.EXTERNALID = KEPT.EXTERNALID AND A.ID <> KEPT.ID);
+INSERT INTO SUBSCRIPTIONS (TELEGRAM_USER_ID, ANIME_ID)
+    SELECT SS.TELEGRAM_USER_ID, DA.KEPT_ID FROM SUBSCRIPTIONS AS SS
+    JOIN DUPLICATE_ANIMES AS DA ON (SS.ANIME_ID = DA.ID)
+    ON CONFLICT (TELEGRAM_USER_ID, ANIME_ID) DO NOTHING;
+DELETE FROM ANIMES WHERE ID IN (SELECT ID FROM DUPLICATE_ANIMES);
+CREATE UNIQUE INDEX ANIMES_EXTERNALID_IDX ON ANIMES(EXTERNALID);
+-- +migrate Down
+DROP INDEX ANIMES_EXTERNALID_IDX;
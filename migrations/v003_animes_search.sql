@@ -0,0 +1,10 @@
+
+-- +migrate Up
+CREATE EXTENSION IF NOT EXISTS PG_TRGM;
+CREATE INDEX ANIMES_ENGNAME_TRGM_IDX ON ANIMES USING GIN (LOWER(ENGNAME) GIN_TRGM_OPS);
+CREATE INDEX ANIMES_RUSNAME_TRGM_IDX ON ANIMES USING GIN (LOWER(RUSNAME) GIN_TRGM_OPS);
+CREATE INDEX ANIMES_SEARCH_IDX ON ANIMES USING GIN (TO_TSVECTOR('simple', ENGNAME || ' ' || RUSNAME));
+-- +migrate Down
+DROP INDEX ANIMES_SEARCH_IDX;
+DROP INDEX ANIMES_RUSNAME_TRGM_IDX;
+DROP INDEX ANIMES_ENGNAME_TRGM_IDX;
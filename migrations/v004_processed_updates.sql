@@ -0,0 +1,9 @@
+
+-- +migrate Up
+CREATE TABLE PROCESSED_UPDATES (
+    UPDATE_ID BIGINT PRIMARY KEY,
+    PROCESSED_AT TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX PROCESSED_UPDATES_PROCESSED_AT_IDX ON PROCESSED_UPDATES(PROCESSED_AT);
+-- +migrate Down
+DROP TABLE PROCESSED_UPDATES;
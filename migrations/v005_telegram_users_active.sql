@@ -0,0 +1,5 @@
+
+-- +migrate Up
+ALTER TABLE TELEGRAM_USERS ADD COLUMN ACTIVE BOOLEAN NOT NULL DEFAULT TRUE;
+-- +migrate Down
+ALTER TABLE TELEGRAM_USERS DROP COLUMN ACTIVE;
@@ -0,0 +1,5 @@
+
+-- +migrate Up
+ALTER TABLE TELEGRAM_USERS ADD COLUMN LOCALE VARCHAR(16);
+-- +migrate Down
+ALTER TABLE TELEGRAM_USERS DROP COLUMN LOCALE;
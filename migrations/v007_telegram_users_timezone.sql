@@ -0,0 +1,5 @@
+
+-- +migrate Up
+ALTER TABLE TELEGRAM_USERS ADD COLUMN TIMEZONE VARCHAR(64);
+-- +migrate Down
+ALTER TABLE TELEGRAM_USERS DROP COLUMN TIMEZONE;
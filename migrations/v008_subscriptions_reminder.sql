@@ -0,0 +1,7 @@
+
+-- +migrate Up
+ALTER TABLE SUBSCRIPTIONS ADD COLUMN REMINDER_LEAD INTEGER;
+ALTER TABLE SUBSCRIPTIONS ADD COLUMN REMINDER_SENT BOOLEAN NOT NULL DEFAULT FALSE;
+-- +migrate Down
+ALTER TABLE SUBSCRIPTIONS DROP COLUMN REMINDER_SENT;
+ALTER TABLE SUBSCRIPTIONS DROP COLUMN REMINDER_LEAD;
@@ -0,0 +1,17 @@
+
+-- +migrate Up
+CREATE TABLE USER_PREFERENCES (
+    TELEGRAM_USER_ID BIGINT PRIMARY KEY REFERENCES TELEGRAM_USERS(ID) ON DELETE CASCADE,
+    NOTIFICATION_MODE VARCHAR(16) NOT NULL DEFAULT 'instant',
+    DIGEST_HOUR SMALLINT NOT NULL DEFAULT 9,
+    LAST_DIGEST_AT TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE TABLE EPISODE_RELEASES (
+    ANIME_ID BIGINT REFERENCES ANIMES(ID) ON DELETE CASCADE,
+    RELEASED_AT TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY(ANIME_ID, RELEASED_AT)
+);
+CREATE INDEX EPISODE_RELEASES_RELEASED_AT_IDX ON EPISODE_RELEASES(RELEASED_AT);
+-- +migrate Down
+DROP TABLE EPISODE_RELEASES;
+DROP TABLE USER_PREFERENCES;
@@ -0,0 +1,15 @@
+
+-- +migrate Up
+ALTER TABLE USER_PREFERENCES ADD COLUMN QUIET_FROM SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE USER_PREFERENCES ADD COLUMN QUIET_TO SMALLINT NOT NULL DEFAULT 0;
+CREATE TABLE HELD_NOTIFICATIONS (
+    ID BIGSERIAL PRIMARY KEY,
+    TELEGRAM_USER_ID BIGINT REFERENCES TELEGRAM_USERS(ID) ON DELETE CASCADE,
+    RELEASE_AT TIMESTAMPTZ NOT NULL,
+    MESSAGE TEXT NOT NULL
+);
+CREATE INDEX HELD_NOTIFICATIONS_RELEASE_AT_IDX ON HELD_NOTIFICATIONS(RELEASE_AT);
+-- +migrate Down
+DROP TABLE HELD_NOTIFICATIONS;
+ALTER TABLE USER_PREFERENCES DROP COLUMN QUIET_TO;
+ALTER TABLE USER_PREFERENCES DROP COLUMN QUIET_FROM;
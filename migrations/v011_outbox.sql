@@ -0,0 +1,13 @@
+
+-- +migrate Up
+CREATE TABLE OUTBOX (
+    ID BIGSERIAL PRIMARY KEY,
+    MESSAGE TEXT NOT NULL,
+    CREATED_AT TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    ATTEMPTS INTEGER NOT NULL DEFAULT 0,
+    NEXT_ATTEMPT_AT TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    DELIVERED_AT TIMESTAMPTZ
+);
+CREATE INDEX OUTBOX_PENDING_IDX ON OUTBOX(NEXT_ATTEMPT_AT, ID) WHERE DELIVERED_AT IS NULL;
+-- +migrate Down
+DROP TABLE OUTBOX;
@@ -0,0 +1,8 @@
+
+-- +migrate Up
+ALTER TABLE OUTBOX ADD COLUMN CONTENT_TYPE VARCHAR(64) NOT NULL DEFAULT 'application/json';
+ALTER TABLE OUTBOX ALTER COLUMN MESSAGE TYPE BYTEA USING CONVERT_TO(MESSAGE, 'UTF8');
+-- +migrate Down
+DELETE FROM OUTBOX WHERE CONTENT_TYPE <> 'application/json';
+ALTER TABLE OUTBOX ALTER COLUMN MESSAGE TYPE TEXT USING CONVERT_FROM(MESSAGE, 'UTF8');
+ALTER TABLE OUTBOX DROP COLUMN CONTENT_TYPE;
@@ -0,0 +1,12 @@
+
+-- +migrate Up
+CREATE TABLE DEAD_LETTERS (
+    ID BIGSERIAL PRIMARY KEY,
+    CHAT_ID BIGINT,
+    CONTENT_TYPE VARCHAR(64) NOT NULL,
+    MESSAGE BYTEA NOT NULL,
+    REASON TEXT NOT NULL,
+    CREATED_AT TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- +migrate Down
+DROP TABLE DEAD_LETTERS;
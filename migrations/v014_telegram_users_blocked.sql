@@ -0,0 +1,5 @@
+
+-- +migrate Up
+ALTER TABLE TELEGRAM_USERS ADD COLUMN BLOCKED BOOLEAN NOT NULL DEFAULT FALSE;
+-- +migrate Down
+ALTER TABLE TELEGRAM_USERS DROP COLUMN BLOCKED;
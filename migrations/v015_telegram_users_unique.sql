@@ -0,0 +1,28 @@
+
+-- +migrate Up
+UPDATE TELEGRAM_USERS AS TU SET ACTIVE = MERGED.ACTIVE, BLOCKED = MERGED.BLOCKED,
+    LOCALE = COALESCE(MERGED.LOCALE, TU.LOCALE), TIMEZONE = COALESCE(MERGED.TIMEZONE, TU.TIMEZONE)
+    FROM (SELECT TELEGRAM_USER_ID, BOOL_AND(ACTIVE) AS ACTIVE, BOOL_OR(BLOCKED) AS BLOCKED,
+        (ARRAY_AGG(LOCALE ORDER BY ID DESC) FILTER (WHERE LOCALE <> ''))[1] AS LOCALE,
+        (ARRAY_AGG(TIMEZONE ORDER BY ID DESC) FILTER (WHERE TIMEZONE <> ''))[1] AS TIMEZONE
+        FROM TELEGRAM_USERS GROUP BY TELEGRAM_USER_ID HAVING COUNT(*) > 1) AS MERGED
+    WHERE TU.TELEGRAM_USER_ID = MERGED.TELEGRAM_USER_ID;
+CREATE TEMPORARY TABLE DUPLICATE_USERS ON COMMIT DROP AS
+    SELECT TU.ID, KEPT.ID AS KEPT_ID FROM TELEGRAM_USERS AS TU
+    JOIN (SELECT TELEGRAM_USER_ID, MIN(ID) AS ID FROM TELEGRAM_USERS GROUP BY TELEGRAM_USER_ID) AS KEPT
+    ON (TU.TELEGRAM_USER_ID = KEPT.TELEGRAM_USER_ID AND TU.ID <> KEPT.ID);
+INSERT INTO SUBSCRIPTIONS (TELEGRAM_USER_ID, ANIME_ID, REMINDER_LEAD, REMINDER_SENT)
+    SELECT DU.KEPT_ID, SS.ANIME_ID, SS.REMINDER_LEAD, SS.REMINDER_SENT FROM SUBSCRIPTIONS AS SS
+    JOIN DUPLICATE_USERS AS DU ON (SS.TELEGRAM_USER_ID = DU.ID)
+    ON CONFLICT (TELEGRAM_USER_ID, ANIME_ID) DO NOTHING;
+DELETE FROM SUBSCRIPTIONS WHERE TELEGRAM_USER_ID IN (SELECT ID FROM DUPLICATE_USERS);
+INSERT INTO USER_PREFERENCES (TELEGRAM_USER_ID, NOTIFICATION_MODE, DIGEST_HOUR, LAST_DIGEST_AT, QUIET_FROM, QUIET_TO)
+    SELECT DISTINCT ON (DU.KEPT_ID) DU.KEPT_ID, UP.NOTIFICATION_MODE, UP.DIGEST_HOUR, UP.LAST_DIGEST_AT, UP.QUIET_FROM, UP.QUIET_TO
+    FROM USER_PREFERENCES AS UP JOIN DUPLICATE_USERS AS DU ON (UP.TELEGRAM_USER_ID = DU.ID)
+    ORDER BY DU.KEPT_ID, DU.ID
+    ON CONFLICT (TELEGRAM_USER_ID) DO NOTHING;
+UPDATE HELD_NOTIFICATIONS AS HN SET TELEGRAM_USER_ID = DU.KEPT_ID FROM DUPLICATE_USERS AS DU WHERE HN.TELEGRAM_USER_ID = DU.ID;
+DELETE FROM TELEGRAM_USERS WHERE ID IN (SELECT ID FROM DUPLICATE_USERS);
+CREATE UNIQUE INDEX TELEGRAM_USERS_TELEGRAM_USER_ID_IDX ON TELEGRAM_USERS(TELEGRAM_USER_ID);
+-- +migrate Down
+DROP INDEX TELEGRAM_USERS_TELEGRAM_USER_ID_IDX;
@@ -0,0 +1,8 @@
+
+-- +migrate Up
+ALTER TABLE HELD_NOTIFICATIONS ADD COLUMN CONTENT_TYPE VARCHAR(64) NOT NULL DEFAULT 'application/json';
+ALTER TABLE HELD_NOTIFICATIONS ALTER COLUMN MESSAGE TYPE BYTEA USING CONVERT_TO(MESSAGE, 'UTF8');
+-- +migrate Down
+DELETE FROM HELD_NOTIFICATIONS WHERE CONTENT_TYPE <> 'application/json';
+ALTER TABLE HELD_NOTIFICATIONS ALTER COLUMN MESSAGE TYPE TEXT USING CONVERT_FROM(MESSAGE, 'UTF8');
+ALTER TABLE HELD_NOTIFICATIONS DROP COLUMN CONTENT_TYPE;
@@ -0,0 +1,7 @@
+
+-- +migrate Up
+ALTER TABLE OUTBOX ADD COLUMN CHAT_ID BIGINT;
+ALTER TABLE HELD_NOTIFICATIONS ADD COLUMN CHAT_ID BIGINT;
+-- +migrate Down
+ALTER TABLE HELD_NOTIFICATIONS DROP COLUMN CHAT_ID;
+ALTER TABLE OUTBOX DROP COLUMN CHAT_ID;
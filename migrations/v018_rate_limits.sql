@@ -0,0 +1,10 @@
+
+-- +migrate Up
+CREATE TABLE RATE_LIMITS (
+    NAME VARCHAR(64) PRIMARY KEY,
+    TOKENS DOUBLE PRECISION NOT NULL,
+    UPDATED_AT TIMESTAMPTZ NOT NULL,
+    BLOCKED_UNTIL TIMESTAMPTZ NOT NULL
+);
+-- +migrate Down
+DROP TABLE RATE_LIMITS;
@@ -0,0 +1,7 @@
+
+-- +migrate Up
+ALTER TABLE OUTBOX ADD COLUMN MESSAGE_ID VARCHAR(36);
+ALTER TABLE HELD_NOTIFICATIONS ADD COLUMN MESSAGE_ID VARCHAR(36);
+-- +migrate Down
+ALTER TABLE HELD_NOTIFICATIONS DROP COLUMN MESSAGE_ID;
+ALTER TABLE OUTBOX DROP COLUMN MESSAGE_ID;
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HDIOES/anime-app/dao"
+	"github.com/HDIOES/anime-app/i18n"
+)
+
+//EpisodeNotifier struct
+type EpisodeNotifier struct {
+	adao     *dao.AnimeDAO
+	sdao     *dao.SubscriptionDAO
+	pdao     *dao.PreferencesDAO
+	digests  *DigestBuilder
+	sender   MessageSender
+	settings *Settings
+	catalog  *i18n.Catalog
+}
+
+//Run func checks for released episodes every NotificationInterval seconds
+func (en *EpisodeNotifier) Run() {
+	ticker := time.NewTicker(time.Duration(en.settings.NotificationInterval) * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := en.notify(); err != nil {
+			HandleError(err)
+		}
+	}
+}
+
+func (en *EpisodeNotifier) notify() error {
+	heldCount, heldErr := en.pdao.ReleaseHeld()
+	if heldCount > 0 {
+		log.Printf("%d held notifications released\n", heldCount)
+	}
+	if heldErr != nil {
+		HandleError(heldErr)
+	}
+	remindersCount, remindErr := en.sdao.RemindUpcoming(en.remindSubscriber)
+	if remindersCount > 0 {
+		log.Printf("%d reminders queued\n", remindersCount)
+	}
+	if remindErr != nil {
+		HandleError(remindErr)
+	}
+	count, err := en.adao.NotifyReleased(en.notifySubscriber)
+	if count > 0 {
+		log.Printf("Notifications queued for %d animes\n", count)
+	}
+	if err != nil {
+		return err
+	}
+	digestsCount, digestErr := en.digests.Build(time.Now())
+	if digestsCount > 0 {
+		log.Printf("Digests built for %d users\n", digestsCount)
+	}
+	return digestErr
+}
+
+func (en *EpisodeNotifier) remindSubscriber(anime *dao.AnimeDTO, subscriber *dao.SubscriberDTO) (*dao.Notification, error) {
+	telegramID, parseErr := strconv.ParseInt(subscriber.ExternalID, 10, 64)
+	if parseErr != nil {
+		HandleError(errors.WithStack(parseErr))
+		return nil, nil
+	}
+	location, timezoneName := userTimezone(en.settings, subscriber.Timezone)
+	ntsMessage := TelegramCommandMessage{
+		TelegramID: telegramID,
+		Type:       reminderType,
+		Text:       en.catalog.Text(subscriber.Locale, reminderKey),
+		InlineAnime: &InlineAnime{
+			InternalID:           anime.ID,
+			AnimeName:            animeName(subscriber.Locale, anime),
+			AnimeThumbnailPicURL: en.settings.ShikimoriURL + anime.ImageURL,
+			UserHasSubscription:  true,
+			AirTime:              en.catalog.Format(subscriber.Locale, nextEpisodeAtKey, formatAirTime(anime.NextEpisodeAt, location, timezoneName)),
+		},
+	}
+	return en.deliver(subscriber, location, &ntsMessage)
+}
+
+//notifySubscriber func returns notification which is written to outbox together with NOTIFICATION_SENT flag
+func (en *EpisodeNotifier) notifySubscriber(anime *dao.AnimeDTO, subscriber *dao.SubscriberDTO) (*dao.Notification, error) {
+	telegramID, parseErr := strconv.ParseInt(subscriber.ExternalID, 10, 64)
+	if parseErr != nil {
+		HandleError(errors.WithStack(parseErr))
+		return nil, nil
+	}
+	location, timezoneName := userTimezone(en.settings, subscriber.Timezone)
+	ntsMessage := TelegramCommandMessage{
+		TelegramID: telegramID,
+		Type:       notificationType,
+		Text:       en.catalog.Text(subscriber.Locale, notificationKey),
+		InlineAnime: &InlineAnime{
+			InternalID:           anime.ID,
+			AnimeName:            animeName(subscriber.Locale, anime),
+			AnimeThumbnailPicURL: en.settings.ShikimoriURL + anime.ImageURL,
+			UserHasSubscription:  true,
+			AirTime:              en.catalog.Format(subscriber.Locale, airedAtKey, formatAirTime(anime.NextEpisodeAt, location, timezoneName)),
+		},
+	}
+	return en.deliver(subscriber, location, &ntsMessage)
+}
+
+//deliver func encodes message to the subscriber, message is held until the end of subscriber's quiet hours
+func (en *EpisodeNotifier) deliver(subscriber *dao.SubscriberDTO, location *time.Location, ntsMessage *TelegramCommandMessage) (*dao.Notification, error) {
+	message, encodeErr := en.sender.Encode(ntsMessage)
+	if encodeErr != nil {
+		return nil, encodeErr
+	}
+	notification := &dao.Notification{Message: message}
+	if releaseAt, quiet := quietUntil(&subscriber.Preferences, time.Now(), location); quiet {
+		notification.HoldUntil = releaseAt
+	}
+	return notification, nil
+}
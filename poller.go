@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	webhookReceiveMode = "webhook"
+	pollingReceiveMode = "polling"
+)
+
+const pollingRetryDelay = 5 * time.Second
+
+//UpdatesPoller struct receives updates via getUpdates Bot API method and feeds them to UpdateDispatcher
+type UpdatesPoller struct {
+	dispatcher UpdateDispatcher
+	client     *http.Client
+	settings   *Settings
+	offset     int64
+}
+
+//Run func polls updates until the process is stopped
+func (up *UpdatesPoller) Run() {
+	for {
+		if err := up.poll(); err != nil {
+			HandleError(err)
+			time.Sleep(pollingRetryDelay)
+		}
+	}
+}
+
+//poll func handles one batch of updates. Like the webhook, offset is not moved past update which failed
+//with any error answered by 5xx status code, so Telegram returns it again on the next call. Client error
+//would repeat on every call, so such update is logged and skipped
+func (up *UpdatesPoller) poll() error {
+	updates, err := up.getUpdates()
+	if err != nil {
+		return err
+	}
+	for i := range updates {
+		update := &updates[i]
+		if handleErr := up.dispatcher.Dispatch(update); handleErr != nil {
+			if statusCode(handleErr) >= http.StatusInternalServerError {
+				return handleErr
+			}
+			HandleError(handleErr)
+		}
+		up.offset = update.UpdateID + 1
+	}
+	return nil
+}
+
+func (up *UpdatesPoller) getUpdates() ([]Update, error) {
+	body, marshalErr := json.Marshal(&GetUpdatesRequest{
+		Offset:  up.offset,
+		Timeout: up.settings.PollingTimeout,
+	})
+	if marshalErr != nil {
+		return nil, errors.WithStack(marshalErr)
+	}
+	methodURL := up.settings.TelegramURL + "/bot" + up.settings.BotToken + "/getUpdates"
+	response, responseErr := up.client.Post(methodURL, "application/json", bytes.NewReader(body))
+	if responseErr != nil {
+		return nil, errors.WithStack(redactToken(responseErr, up.settings.BotToken))
+	}
+	defer response.Body.Close()
+	respReader, logRespErr := logResponse(response)
+	if logRespErr != nil {
+		return nil, logRespErr
+	}
+	getUpdatesResponse := &GetUpdatesResponse{}
+	if decodeErr := json.NewDecoder(respReader).Decode(getUpdatesResponse); decodeErr != nil {
+		return nil, errors.WithStack(decodeErr)
+	}
+	if !getUpdatesResponse.Ok {
+		return nil, errors.Errorf("getUpdates failed with code %d: %s", getUpdatesResponse.ErrorCode, getUpdatesResponse.Description)
+	}
+	return getUpdatesResponse.Result, nil
+}
+
+//GetUpdatesRequest struct
+type GetUpdatesRequest struct {
+	Offset  int64 `json:"offset"`
+	Timeout int   `json:"timeout"`
+}
+
+//GetUpdatesResponse struct
+type GetUpdatesResponse struct {
+	Ok          bool     `json:"ok"`
+	Result      []Update `json:"result"`
+	ErrorCode   int      `json:"error_code"`
+	Description string   `json:"description"`
+}
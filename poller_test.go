@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+//fakeGetUpdates func starts getUpdates stand-in which returns updates with ID not less than requested offset
+func fakeGetUpdates(t *testing.T, updateIDs ...int64) (*httptest.Server, *[]int64) {
+	offsets := make([]int64, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bot"+testBotToken+"/getUpdates" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		request := &GetUpdatesRequest{}
+		if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+			t.Errorf("getUpdates request is not JSON: %v", err)
+		}
+		offsets = append(offsets, request.Offset)
+		response := &GetUpdatesResponse{Ok: true, Result: make([]Update, 0)}
+		for _, updateID := range updateIDs {
+			if updateID >= request.Offset {
+				response.Result = append(response.Result, Update{UpdateID: updateID})
+			}
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+	return server, &offsets
+}
+
+func newTestPoller(server *httptest.Server, dispatch func(update *Update) error) *UpdatesPoller {
+	return &UpdatesPoller{
+		dispatcher: dispatcherFunc(dispatch),
+		client:     server.Client(),
+		settings:   &Settings{TelegramURL: server.URL, BotToken: testBotToken},
+	}
+}
+
+func TestPollerSkipsUpdateWithClientError(t *testing.T) {
+	server, offsets := fakeGetUpdates(t, 10, 11)
+	poller := newTestPoller(server, func(update *Update) error {
+		if update.UpdateID == 10 {
+			return newClientError(errors.New("Bad request"))
+		}
+		return newClientError(errors.New("Unknown command"))
+	})
+	if err := poller.poll(); err != nil {
+		t.Fatal(err)
+	}
+	if err := poller.poll(); err != nil {
+		t.Fatal(err)
+	}
+	if len(*offsets) != 2 || (*offsets)[1] != 12 {
+		t.Fatalf("requested offsets are %v, want second offset 12", *offsets)
+	}
+}
+
+func TestPollerRepeatsUpdateWithServerFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{name: "transient failure", err: newTransientError(errors.New("connection refused")), code: http.StatusServiceUnavailable},
+		{name: "unclassified failure", err: errors.New("unexpected"), code: http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, offsets := fakeGetUpdates(t, 10, 11)
+			dispatched := make([]int64, 0)
+			poller := newTestPoller(server, func(update *Update) error {
+				dispatched = append(dispatched, update.UpdateID)
+				if update.UpdateID == 11 {
+					return test.err
+				}
+				return nil
+			})
+			if err := poller.poll(); statusCode(err) != test.code {
+				t.Fatalf("poll returned %v, want status code %d", err, test.code)
+			}
+			if err := poller.poll(); err == nil {
+				t.Fatal("poll of repeated update succeeded")
+			}
+			if len(*offsets) != 2 || (*offsets)[1] != 11 {
+				t.Fatalf("requested offsets are %v, want second offset 11", *offsets)
+			}
+			if len(dispatched) != 3 || dispatched[2] != 11 {
+				t.Fatalf("dispatched updates are %v, want 11 again", dispatched)
+			}
+		})
+	}
+}
+
+func TestPollerRedactsTokenOfFailedRequest(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	poller := newTestPoller(server, func(update *Update) error { return nil })
+	err := poller.poll()
+	if err == nil {
+		t.Fatal("poll of closed server succeeded")
+	}
+	if strings.Contains(err.Error(), testBotToken) {
+		t.Fatalf("error contains bot token: %v", err)
+	}
+}
@@ -0,0 +1,1183 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.26.0
+// 	protoc        (unknown)
+// source: proto/telegram_command.proto
+
+package proto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Envelope struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SchemaVersion int32                  `protobuf:"varint,1,opt,name=schema_version,json=schemaVersion,proto3" json:"schema_version,omitempty"`
+	MessageId     string                 `protobuf:"bytes,2,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Type          string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	// Types that are assignable to Payload:
+	//	*Envelope_ChatMessage
+	//	*Envelope_Digest
+	//	*Envelope_List
+	//	*Envelope_AnswerQuery
+	//	*Envelope_Subscription
+	//	*Envelope_EditText
+	//	*Envelope_AnswerCallback
+	Payload isEnvelope_Payload `protobuf_oneof:"payload"`
+}
+
+func (x *Envelope) Reset() {
+	*x = Envelope{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_telegram_command_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Envelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Envelope) ProtoMessage() {}
+
+func (x *Envelope) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_command_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Envelope.ProtoReflect.Descriptor instead.
+func (*Envelope) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_command_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Envelope) GetSchemaVersion() int32 {
+	if x != nil {
+		return x.SchemaVersion
+	}
+	return 0
+}
+
+func (x *Envelope) GetMessageId() string {
+	if x != nil {
+		return x.MessageId
+	}
+	return ""
+}
+
+func (x *Envelope) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Envelope) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (m *Envelope) GetPayload() isEnvelope_Payload {
+	if m != nil {
+		return m.Payload
+	}
+	return nil
+}
+
+func (x *Envelope) GetChatMessage() *ChatMessagePayload {
+	if x, ok := x.GetPayload().(*Envelope_ChatMessage); ok {
+		return x.ChatMessage
+	}
+	return nil
+}
+
+func (x *Envelope) GetDigest() *DigestPayload {
+	if x, ok := x.GetPayload().(*Envelope_Digest); ok {
+		return x.Digest
+	}
+	return nil
+}
+
+func (x *Envelope) GetList() *ListPayload {
+	if x, ok := x.GetPayload().(*Envelope_List); ok {
+		return x.List
+	}
+	return nil
+}
+
+func (x *Envelope) GetAnswerQuery() *AnswerQueryPayload {
+	if x, ok := x.GetPayload().(*Envelope_AnswerQuery); ok {
+		return x.AnswerQuery
+	}
+	return nil
+}
+
+func (x *Envelope) GetSubscription() *SubscriptionPayload {
+	if x, ok := x.GetPayload().(*Envelope_Subscription); ok {
+		return x.Subscription
+	}
+	return nil
+}
+
+func (x *Envelope) GetEditText() *EditTextPayload {
+	if x, ok := x.GetPayload().(*Envelope_EditText); ok {
+		return x.EditText
+	}
+	return nil
+}
+
+func (x *Envelope) GetAnswerCallback() *AnswerCallbackPayload {
+	if x, ok := x.GetPayload().(*Envelope_AnswerCallback); ok {
+		return x.AnswerCallback
+	}
+	return nil
+}
+
+type isEnvelope_Payload interface {
+	isEnvelope_Payload()
+}
+
+type Envelope_ChatMessage struct {
+	ChatMessage *ChatMessagePayload `protobuf:"bytes,10,opt,name=chat_message,json=chatMessage,proto3,oneof"`
+}
+
+type Envelope_Digest struct {
+	Digest *DigestPayload `protobuf:"bytes,11,opt,name=digest,proto3,oneof"`
+}
+
+type Envelope_List struct {
+	List *ListPayload `protobuf:"bytes,12,opt,name=list,proto3,oneof"`
+}
+
+type Envelope_AnswerQuery struct {
+	AnswerQuery *AnswerQueryPayload `protobuf:"bytes,13,opt,name=answer_query,json=answerQuery,proto3,oneof"`
+}
+
+type Envelope_Subscription struct {
+	Subscription *SubscriptionPayload `protobuf:"bytes,14,opt,name=subscription,proto3,oneof"`
+}
+
+type Envelope_EditText struct {
+	EditText *EditTextPayload `protobuf:"bytes,15,opt,name=edit_text,json=editText,proto3,oneof"`
+}
+
+type Envelope_AnswerCallback struct {
+	AnswerCallback *AnswerCallbackPayload `protobuf:"bytes,16,opt,name=answer_callback,json=answerCallback,proto3,oneof"`
+}
+
+func (*Envelope_ChatMessage) isEnvelope_Payload() {}
+
+func (*Envelope_Digest) isEnvelope_Payload() {}
+
+func (*Envelope_List) isEnvelope_Payload() {}
+
+func (*Envelope_AnswerQuery) isEnvelope_Payload() {}
+
+func (*Envelope_Subscription) isEnvelope_Payload() {}
+
+func (*Envelope_EditText) isEnvelope_Payload() {}
+
+func (*Envelope_AnswerCallback) isEnvelope_Payload() {}
+
+type ChatMessagePayload struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TelegramId int64     `protobuf:"varint,1,opt,name=telegram_id,json=telegramId,proto3" json:"telegram_id,omitempty"`
+	Text       string    `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	Anime      *Anime    `protobuf:"bytes,3,opt,name=anime,proto3" json:"anime,omitempty"`
+	Buttons    []*Button `protobuf:"bytes,4,rep,name=buttons,proto3" json:"buttons,omitempty"`
+}
+
+func (x *ChatMessagePayload) Reset() {
+	*x = ChatMessagePayload{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_telegram_command_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ChatMessagePayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChatMessagePayload) ProtoMessage() {}
+
+func (x *ChatMessagePayload) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_command_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChatMessagePayload.ProtoReflect.Descriptor instead.
+func (*ChatMessagePayload) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_command_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ChatMessagePayload) GetTelegramId() int64 {
+	if x != nil {
+		return x.TelegramId
+	}
+	return 0
+}
+
+func (x *ChatMessagePayload) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *ChatMessagePayload) GetAnime() *Anime {
+	if x != nil {
+		return x.Anime
+	}
+	return nil
+}
+
+func (x *ChatMessagePayload) GetButtons() []*Button {
+	if x != nil {
+		return x.Buttons
+	}
+	return nil
+}
+
+type DigestPayload struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TelegramId int64    `protobuf:"varint,1,opt,name=telegram_id,json=telegramId,proto3" json:"telegram_id,omitempty"`
+	Text       string   `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	Animes     []*Anime `protobuf:"bytes,3,rep,name=animes,proto3" json:"animes,omitempty"`
+}
+
+func (x *DigestPayload) Reset() {
+	*x = DigestPayload{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_telegram_command_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DigestPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DigestPayload) ProtoMessage() {}
+
+func (x *DigestPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_command_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DigestPayload.ProtoReflect.Descriptor instead.
+func (*DigestPayload) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_command_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DigestPayload) GetTelegramId() int64 {
+	if x != nil {
+		return x.TelegramId
+	}
+	return 0
+}
+
+func (x *DigestPayload) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *DigestPayload) GetAnimes() []*Anime {
+	if x != nil {
+		return x.Animes
+	}
+	return nil
+}
+
+type ListPayload struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TelegramId      int64    `protobuf:"varint,1,opt,name=telegram_id,json=telegramId,proto3" json:"telegram_id,omitempty"`
+	Text            string   `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	Animes          []*Anime `protobuf:"bytes,3,rep,name=animes,proto3" json:"animes,omitempty"`
+	Page            int64    `protobuf:"varint,4,opt,name=page,proto3" json:"page,omitempty"`
+	HasNextPage     bool     `protobuf:"varint,5,opt,name=has_next_page,json=hasNextPage,proto3" json:"has_next_page,omitempty"`
+	ChatId          int64    `protobuf:"varint,6,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
+	MessageId       int64    `protobuf:"varint,7,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	CallbackQueryId string   `protobuf:"bytes,8,opt,name=callback_query_id,json=callbackQueryId,proto3" json:"callback_query_id,omitempty"`
+}
+
+func (x *ListPayload) Reset() {
+	*x = ListPayload{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_telegram_command_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPayload) ProtoMessage() {}
+
+func (x *ListPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_command_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPayload.ProtoReflect.Descriptor instead.
+func (*ListPayload) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_command_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListPayload) GetTelegramId() int64 {
+	if x != nil {
+		return x.TelegramId
+	}
+	return 0
+}
+
+func (x *ListPayload) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *ListPayload) GetAnimes() []*Anime {
+	if x != nil {
+		return x.Animes
+	}
+	return nil
+}
+
+func (x *ListPayload) GetPage() int64 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListPayload) GetHasNextPage() bool {
+	if x != nil {
+		return x.HasNextPage
+	}
+	return false
+}
+
+func (x *ListPayload) GetChatId() int64 {
+	if x != nil {
+		return x.ChatId
+	}
+	return 0
+}
+
+func (x *ListPayload) GetMessageId() int64 {
+	if x != nil {
+		return x.MessageId
+	}
+	return 0
+}
+
+func (x *ListPayload) GetCallbackQueryId() string {
+	if x != nil {
+		return x.CallbackQueryId
+	}
+	return ""
+}
+
+type AnswerQueryPayload struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	InlineQueryId string   `protobuf:"bytes,1,opt,name=inline_query_id,json=inlineQueryId,proto3" json:"inline_query_id,omitempty"`
+	Animes        []*Anime `protobuf:"bytes,2,rep,name=animes,proto3" json:"animes,omitempty"`
+	NextOffset    string   `protobuf:"bytes,3,opt,name=next_offset,json=nextOffset,proto3" json:"next_offset,omitempty"`
+}
+
+func (x *AnswerQueryPayload) Reset() {
+	*x = AnswerQueryPayload{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_telegram_command_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AnswerQueryPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnswerQueryPayload) ProtoMessage() {}
+
+func (x *AnswerQueryPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_command_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnswerQueryPayload.ProtoReflect.Descriptor instead.
+func (*AnswerQueryPayload) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_command_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AnswerQueryPayload) GetInlineQueryId() string {
+	if x != nil {
+		return x.InlineQueryId
+	}
+	return ""
+}
+
+func (x *AnswerQueryPayload) GetAnimes() []*Anime {
+	if x != nil {
+		return x.Animes
+	}
+	return nil
+}
+
+func (x *AnswerQueryPayload) GetNextOffset() string {
+	if x != nil {
+		return x.NextOffset
+	}
+	return ""
+}
+
+type SubscriptionPayload struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ChatId          int64  `protobuf:"varint,1,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
+	MessageId       int64  `protobuf:"varint,2,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	CallbackQueryId string `protobuf:"bytes,3,opt,name=callback_query_id,json=callbackQueryId,proto3" json:"callback_query_id,omitempty"`
+	AnimeId         int64  `protobuf:"varint,4,opt,name=anime_id,json=animeId,proto3" json:"anime_id,omitempty"`
+}
+
+func (x *SubscriptionPayload) Reset() {
+	*x = SubscriptionPayload{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_telegram_command_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubscriptionPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscriptionPayload) ProtoMessage() {}
+
+func (x *SubscriptionPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_command_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscriptionPayload.ProtoReflect.Descriptor instead.
+func (*SubscriptionPayload) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_command_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SubscriptionPayload) GetChatId() int64 {
+	if x != nil {
+		return x.ChatId
+	}
+	return 0
+}
+
+func (x *SubscriptionPayload) GetMessageId() int64 {
+	if x != nil {
+		return x.MessageId
+	}
+	return 0
+}
+
+func (x *SubscriptionPayload) GetCallbackQueryId() string {
+	if x != nil {
+		return x.CallbackQueryId
+	}
+	return ""
+}
+
+func (x *SubscriptionPayload) GetAnimeId() int64 {
+	if x != nil {
+		return x.AnimeId
+	}
+	return 0
+}
+
+type EditTextPayload struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ChatId          int64  `protobuf:"varint,1,opt,name=chat_id,json=chatId,proto3" json:"chat_id,omitempty"`
+	MessageId       int64  `protobuf:"varint,2,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"`
+	CallbackQueryId string `protobuf:"bytes,3,opt,name=callback_query_id,json=callbackQueryId,proto3" json:"callback_query_id,omitempty"`
+	Text            string `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+}
+
+func (x *EditTextPayload) Reset() {
+	*x = EditTextPayload{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_telegram_command_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *EditTextPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EditTextPayload) ProtoMessage() {}
+
+func (x *EditTextPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_command_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EditTextPayload.ProtoReflect.Descriptor instead.
+func (*EditTextPayload) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_command_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *EditTextPayload) GetChatId() int64 {
+	if x != nil {
+		return x.ChatId
+	}
+	return 0
+}
+
+func (x *EditTextPayload) GetMessageId() int64 {
+	if x != nil {
+		return x.MessageId
+	}
+	return 0
+}
+
+func (x *EditTextPayload) GetCallbackQueryId() string {
+	if x != nil {
+		return x.CallbackQueryId
+	}
+	return ""
+}
+
+func (x *EditTextPayload) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+type AnswerCallbackPayload struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	CallbackQueryId string `protobuf:"bytes,1,opt,name=callback_query_id,json=callbackQueryId,proto3" json:"callback_query_id,omitempty"`
+	Text            string `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+}
+
+func (x *AnswerCallbackPayload) Reset() {
+	*x = AnswerCallbackPayload{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_telegram_command_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AnswerCallbackPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AnswerCallbackPayload) ProtoMessage() {}
+
+func (x *AnswerCallbackPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_command_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AnswerCallbackPayload.ProtoReflect.Descriptor instead.
+func (*AnswerCallbackPayload) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_command_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *AnswerCallbackPayload) GetCallbackQueryId() string {
+	if x != nil {
+		return x.CallbackQueryId
+	}
+	return ""
+}
+
+func (x *AnswerCallbackPayload) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+type Anime struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	ThumbnailUrl  string                 `protobuf:"bytes,3,opt,name=thumbnail_url,json=thumbnailUrl,proto3" json:"thumbnail_url,omitempty"`
+	Subscribed    bool                   `protobuf:"varint,4,opt,name=subscribed,proto3" json:"subscribed,omitempty"`
+	NextEpisodeAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=next_episode_at,json=nextEpisodeAt,proto3" json:"next_episode_at,omitempty"`
+	AirTime       string                 `protobuf:"bytes,6,opt,name=air_time,json=airTime,proto3" json:"air_time,omitempty"`
+}
+
+func (x *Anime) Reset() {
+	*x = Anime{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_telegram_command_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Anime) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Anime) ProtoMessage() {}
+
+func (x *Anime) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_command_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Anime.ProtoReflect.Descriptor instead.
+func (*Anime) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_command_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Anime) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Anime) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Anime) GetThumbnailUrl() string {
+	if x != nil {
+		return x.ThumbnailUrl
+	}
+	return ""
+}
+
+func (x *Anime) GetSubscribed() bool {
+	if x != nil {
+		return x.Subscribed
+	}
+	return false
+}
+
+func (x *Anime) GetNextEpisodeAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NextEpisodeAt
+	}
+	return nil
+}
+
+func (x *Anime) GetAirTime() string {
+	if x != nil {
+		return x.AirTime
+	}
+	return ""
+}
+
+type Button struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Text         string `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	CallbackData string `protobuf:"bytes,2,opt,name=callback_data,json=callbackData,proto3" json:"callback_data,omitempty"`
+}
+
+func (x *Button) Reset() {
+	*x = Button{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_telegram_command_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Button) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Button) ProtoMessage() {}
+
+func (x *Button) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_telegram_command_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Button.ProtoReflect.Descriptor instead.
+func (*Button) Descriptor() ([]byte, []int) {
+	return file_proto_telegram_command_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Button) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Button) GetCallbackData() string {
+	if x != nil {
+		return x.CallbackData
+	}
+	return ""
+}
+
+var File_proto_telegram_command_proto protoreflect.FileDescriptor
+
+var file_proto_telegram_command_proto_rawDesc = []byte{
+	0x0a, 0x1c, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x74, 0x65, 0x6c, 0x65, 0x67, 0x72, 0x61, 0x6d,
+	0x5f, 0x63, 0x6f, 0x6d, 0x6d, 0x61, 0x6e, 0x64, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0b,
+	0x61, 0x6e, 0x69, 0x6d, 0x65, 0x61, 0x70, 0x70, 0x2e, 0x76, 0x32, 0x1a, 0x1f, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xef, 0x04, 0x0a,
+	0x08, 0x45, 0x6e, 0x76, 0x65, 0x6c, 0x6f, 0x70, 0x65, 0x12, 0x25, 0x0a, 0x0e, 0x73, 0x63, 0x68,
+	0x65, 0x6d, 0x61, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x0d, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x12, 0x1d, 0x0a, 0x0a, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x49, 0x64, 0x12,
+	0x38, 0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70,
+	0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x44, 0x0a,
+	0x0c, 0x63, 0x68, 0x61, 0x74, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x0a, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1f, 0x2e, 0x61, 0x6e, 0x69, 0x6d, 0x65, 0x61, 0x70, 0x70, 0x2e, 0x76,
+	0x32, 0x2e, 0x43, 0x68, 0x61, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x50, 0x61, 0x79,
+	0x6c, 0x6f, 0x61, 0x64, 0x48, 0x00, 0x52, 0x0b, 0x63, 0x68, 0x61, 0x74, 0x4d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x12, 0x34, 0x0a, 0x06, 0x64, 0x69, 0x67, 0x65, 0x73, 0x74, 0x18, 0x0b, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x61, 0x6e, 0x69, 0x6d, 0x65, 0x61, 0x70, 0x70, 0x2e, 0x76,
+	0x32, 0x2e, 0x44, 0x69, 0x67, 0x65, 0x73, 0x74, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x48,
+	0x00, 0x52, 0x06, 0x64, 0x69, 0x67, 0x65, 0x73, 0x74, 0x12, 0x2e, 0x0a, 0x04, 0x6c, 0x69, 0x73,
+	0x74, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x61, 0x6e, 0x69, 0x6d, 0x65, 0x61,
+	0x70, 0x70, 0x2e, 0x76, 0x32, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61,
+	0x64, 0x48, 0x00, 0x52, 0x04, 0x6c, 0x69, 0x73, 0x74, 0x12, 0x44, 0x0a, 0x0c, 0x61, 0x6e, 0x73,
+	0x77, 0x65, 0x72, 0x5f, 0x71, 0x75, 0x65, 0x72, 0x79, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1f, 0x2e, 0x61, 0x6e, 0x69, 0x6d, 0x65, 0x61, 0x70, 0x70, 0x2e, 0x76, 0x32, 0x2e, 0x41, 0x6e,
+	0x73, 0x77, 0x65, 0x72, 0x51, 0x75, 0x65, 0x72, 0x79, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64,
+	0x48, 0x00, 0x52, 0x0b, 0x61, 0x6e, 0x73, 0x77, 0x65, 0x72, 0x51, 0x75, 0x65, 0x72, 0x79, 0x12,
+	0x46, 0x0a, 0x0c, 0x73, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18,
+	0x0e, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x61, 0x6e, 0x69, 0x6d, 0x65, 0x61, 0x70, 0x70,
+	0x2e, 0x76, 0x32, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e,
+	0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x48, 0x00, 0x52, 0x0c, 0x73, 0x75, 0x62, 0x73, 0x63,
+	0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x3b, 0x0a, 0x09, 0x65, 0x64, 0x69, 0x74, 0x5f,
+	0x74, 0x65, 0x78, 0x74, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x61, 0x6e, 0x69,
+	0x6d, 0x65, 0x61, 0x70, 0x70, 0x2e, 0x76, 0x32, 0x2e, 0x45, 0x64, 0x69, 0x74, 0x54, 0x65, 0x78,
+	0x74, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x48, 0x00, 0x52, 0x08, 0x65, 0x64, 0x69, 0x74,
+	0x54, 0x65, 0x78, 0x74, 0x12, 0x4d, 0x0a, 0x0f, 0x61, 0x6e, 0x73, 0x77, 0x65, 0x72, 0x5f, 0x63,
+	0x61, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x18, 0x10, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x22, 0x2e,
+	0x61, 0x6e, 0x69, 0x6d, 0x65, 0x61, 0x70, 0x70, 0x2e, 0x76, 0x32, 0x2e, 0x41, 0x6e, 0x73, 0x77,
+	0x65, 0x72, 0x43, 0x61, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61,
+	0x64, 0x48, 0x00, 0x52, 0x0e, 0x61, 0x6e, 0x73, 0x77, 0x65, 0x72, 0x43, 0x61, 0x6c, 0x6c, 0x62,
+	0x61, 0x63, 0x6b, 0x42, 0x09, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0xa2,
+	0x01, 0x0a, 0x12, 0x43, 0x68, 0x61, 0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x50, 0x61,
+	0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x74, 0x65, 0x6c, 0x65, 0x67, 0x72, 0x61,
+	0x6d, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x74, 0x65, 0x6c, 0x65,
+	0x67, 0x72, 0x61, 0x6d, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x28, 0x0a, 0x05, 0x61, 0x6e,
+	0x69, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x61, 0x6e, 0x69, 0x6d,
+	0x65, 0x61, 0x70, 0x70, 0x2e, 0x76, 0x32, 0x2e, 0x41, 0x6e, 0x69, 0x6d, 0x65, 0x52, 0x05, 0x61,
+	0x6e, 0x69, 0x6d, 0x65, 0x12, 0x2d, 0x0a, 0x07, 0x62, 0x75, 0x74, 0x74, 0x6f, 0x6e, 0x73, 0x18,
+	0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x61, 0x6e, 0x69, 0x6d, 0x65, 0x61, 0x70, 0x70,
+	0x2e, 0x76, 0x32, 0x2e, 0x42, 0x75, 0x74, 0x74, 0x6f, 0x6e, 0x52, 0x07, 0x62, 0x75, 0x74, 0x74,
+	0x6f, 0x6e, 0x73, 0x22, 0x70, 0x0a, 0x0d, 0x44, 0x69, 0x67, 0x65, 0x73, 0x74, 0x50, 0x61, 0x79,
+	0x6c, 0x6f, 0x61, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x74, 0x65, 0x6c, 0x65, 0x67, 0x72, 0x61, 0x6d,
+	0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x74, 0x65, 0x6c, 0x65, 0x67,
+	0x72, 0x61, 0x6d, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x2a, 0x0a, 0x06, 0x61, 0x6e, 0x69,
+	0x6d, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x61, 0x6e, 0x69, 0x6d,
+	0x65, 0x61, 0x70, 0x70, 0x2e, 0x76, 0x32, 0x2e, 0x41, 0x6e, 0x69, 0x6d, 0x65, 0x52, 0x06, 0x61,
+	0x6e, 0x69, 0x6d, 0x65, 0x73, 0x22, 0x8a, 0x02, 0x0a, 0x0b, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x61,
+	0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x74, 0x65, 0x6c, 0x65, 0x67, 0x72, 0x61,
+	0x6d, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x74, 0x65, 0x6c, 0x65,
+	0x67, 0x72, 0x61, 0x6d, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x2a, 0x0a, 0x06, 0x61, 0x6e,
+	0x69, 0x6d, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x61, 0x6e, 0x69,
+	0x6d, 0x65, 0x61, 0x70, 0x70, 0x2e, 0x76, 0x32, 0x2e, 0x41, 0x6e, 0x69, 0x6d, 0x65, 0x52, 0x06,
+	0x61, 0x6e, 0x69, 0x6d, 0x65, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x67, 0x65, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x70, 0x61, 0x67, 0x65, 0x12, 0x22, 0x0a, 0x0d, 0x68, 0x61,
+	0x73, 0x5f, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x70, 0x61, 0x67, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x0b, 0x68, 0x61, 0x73, 0x4e, 0x65, 0x78, 0x74, 0x50, 0x61, 0x67, 0x65, 0x12, 0x17,
+	0x0a, 0x07, 0x63, 0x68, 0x61, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x06, 0x63, 0x68, 0x61, 0x74, 0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x6d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x6d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x49, 0x64, 0x12, 0x2a, 0x0a, 0x11, 0x63, 0x61, 0x6c, 0x6c, 0x62, 0x61,
+	0x63, 0x6b, 0x5f, 0x71, 0x75, 0x65, 0x72, 0x79, 0x5f, 0x69, 0x64, 0x18, 0x08, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0f, 0x63, 0x61, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x51, 0x75, 0x65, 0x72, 0x79,
+	0x49, 0x64, 0x22, 0x89, 0x01, 0x0a, 0x12, 0x41, 0x6e, 0x73, 0x77, 0x65, 0x72, 0x51, 0x75, 0x65,
+	0x72, 0x79, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x26, 0x0a, 0x0f, 0x69, 0x6e, 0x6c,
+	0x69, 0x6e, 0x65, 0x5f, 0x71, 0x75, 0x65, 0x72, 0x79, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0d, 0x69, 0x6e, 0x6c, 0x69, 0x6e, 0x65, 0x51, 0x75, 0x65, 0x72, 0x79, 0x49,
+	0x64, 0x12, 0x2a, 0x0a, 0x06, 0x61, 0x6e, 0x69, 0x6d, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x12, 0x2e, 0x61, 0x6e, 0x69, 0x6d, 0x65, 0x61, 0x70, 0x70, 0x2e, 0x76, 0x32, 0x2e,
+	0x41, 0x6e, 0x69, 0x6d, 0x65, 0x52, 0x06, 0x61, 0x6e, 0x69, 0x6d, 0x65, 0x73, 0x12, 0x1f, 0x0a,
+	0x0b, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0a, 0x6e, 0x65, 0x78, 0x74, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x22, 0x94,
+	0x01, 0x0a, 0x13, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x50,
+	0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x63, 0x68, 0x61, 0x74, 0x5f, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x63, 0x68, 0x61, 0x74, 0x49, 0x64, 0x12,
+	0x1d, 0x0a, 0x0a, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x09, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x49, 0x64, 0x12, 0x2a,
+	0x0a, 0x11, 0x63, 0x61, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x5f, 0x71, 0x75, 0x65, 0x72, 0x79,
+	0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x63, 0x61, 0x6c, 0x6c, 0x62,
+	0x61, 0x63, 0x6b, 0x51, 0x75, 0x65, 0x72, 0x79, 0x49, 0x64, 0x12, 0x19, 0x0a, 0x08, 0x61, 0x6e,
+	0x69, 0x6d, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x61, 0x6e,
+	0x69, 0x6d, 0x65, 0x49, 0x64, 0x22, 0x89, 0x01, 0x0a, 0x0f, 0x45, 0x64, 0x69, 0x74, 0x54, 0x65,
+	0x78, 0x74, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x63, 0x68, 0x61,
+	0x74, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x63, 0x68, 0x61, 0x74,
+	0x49, 0x64, 0x12, 0x1d, 0x0a, 0x0a, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x69, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x49,
+	0x64, 0x12, 0x2a, 0x0a, 0x11, 0x63, 0x61, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x5f, 0x71, 0x75,
+	0x65, 0x72, 0x79, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x63, 0x61,
+	0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x51, 0x75, 0x65, 0x72, 0x79, 0x49, 0x64, 0x12, 0x12, 0x0a,
+	0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78,
+	0x74, 0x22, 0x57, 0x0a, 0x15, 0x41, 0x6e, 0x73, 0x77, 0x65, 0x72, 0x43, 0x61, 0x6c, 0x6c, 0x62,
+	0x61, 0x63, 0x6b, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x2a, 0x0a, 0x11, 0x63, 0x61,
+	0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x5f, 0x71, 0x75, 0x65, 0x72, 0x79, 0x5f, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x63, 0x61, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x51,
+	0x75, 0x65, 0x72, 0x79, 0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x22, 0xcf, 0x01, 0x0a, 0x05, 0x41,
+	0x6e, 0x69, 0x6d, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x74, 0x68, 0x75, 0x6d,
+	0x62, 0x6e, 0x61, 0x69, 0x6c, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0c, 0x74, 0x68, 0x75, 0x6d, 0x62, 0x6e, 0x61, 0x69, 0x6c, 0x55, 0x72, 0x6c, 0x12, 0x1e, 0x0a,
+	0x0a, 0x73, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x0a, 0x73, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x64, 0x12, 0x42, 0x0a,
+	0x0f, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x65, 0x70, 0x69, 0x73, 0x6f, 0x64, 0x65, 0x5f, 0x61, 0x74,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x52, 0x0d, 0x6e, 0x65, 0x78, 0x74, 0x45, 0x70, 0x69, 0x73, 0x6f, 0x64, 0x65, 0x41,
+	0x74, 0x12, 0x19, 0x0a, 0x08, 0x61, 0x69, 0x72, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x69, 0x72, 0x54, 0x69, 0x6d, 0x65, 0x22, 0x41, 0x0a, 0x06,
+	0x42, 0x75, 0x74, 0x74, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x65, 0x78, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x74, 0x65, 0x78, 0x74, 0x12, 0x23, 0x0a, 0x0d, 0x63, 0x61,
+	0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x5f, 0x64, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0c, 0x63, 0x61, 0x6c, 0x6c, 0x62, 0x61, 0x63, 0x6b, 0x44, 0x61, 0x74, 0x61, 0x42,
+	0x23, 0x5a, 0x21, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x48, 0x44,
+	0x49, 0x4f, 0x45, 0x53, 0x2f, 0x61, 0x6e, 0x69, 0x6d, 0x65, 0x2d, 0x61, 0x70, 0x70, 0x2f, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_proto_telegram_command_proto_rawDescOnce sync.Once
+	file_proto_telegram_command_proto_rawDescData = file_proto_telegram_command_proto_rawDesc
+)
+
+func file_proto_telegram_command_proto_rawDescGZIP() []byte {
+	file_proto_telegram_command_proto_rawDescOnce.Do(func() {
+		file_proto_telegram_command_proto_rawDescData = protoimpl.X.CompressGZIP(file_proto_telegram_command_proto_rawDescData)
+	})
+	return file_proto_telegram_command_proto_rawDescData
+}
+
+var file_proto_telegram_command_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_proto_telegram_command_proto_goTypes = []interface{}{
+	(*Envelope)(nil),              // 0: animeapp.v2.Envelope
+	(*ChatMessagePayload)(nil),    // 1: animeapp.v2.ChatMessagePayload
+	(*DigestPayload)(nil),         // 2: animeapp.v2.DigestPayload
+	(*ListPayload)(nil),           // 3: animeapp.v2.ListPayload
+	(*AnswerQueryPayload)(nil),    // 4: animeapp.v2.AnswerQueryPayload
+	(*SubscriptionPayload)(nil),   // 5: animeapp.v2.SubscriptionPayload
+	(*EditTextPayload)(nil),       // 6: animeapp.v2.EditTextPayload
+	(*AnswerCallbackPayload)(nil), // 7: animeapp.v2.AnswerCallbackPayload
+	(*Anime)(nil),                 // 8: animeapp.v2.Anime
+	(*Button)(nil),                // 9: animeapp.v2.Button
+	(*timestamppb.Timestamp)(nil), // 10: google.protobuf.Timestamp
+}
+var file_proto_telegram_command_proto_depIdxs = []int32{
+	10, // 0: animeapp.v2.Envelope.timestamp:type_name -> google.protobuf.Timestamp
+	1,  // 1: animeapp.v2.Envelope.chat_message:type_name -> animeapp.v2.ChatMessagePayload
+	2,  // 2: animeapp.v2.Envelope.digest:type_name -> animeapp.v2.DigestPayload
+	3,  // 3: animeapp.v2.Envelope.list:type_name -> animeapp.v2.ListPayload
+	4,  // 4: animeapp.v2.Envelope.answer_query:type_name -> animeapp.v2.AnswerQueryPayload
+	5,  // 5: animeapp.v2.Envelope.subscription:type_name -> animeapp.v2.SubscriptionPayload
+	6,  // 6: animeapp.v2.Envelope.edit_text:type_name -> animeapp.v2.EditTextPayload
+	7,  // 7: animeapp.v2.Envelope.answer_callback:type_name -> animeapp.v2.AnswerCallbackPayload
+	8,  // 8: animeapp.v2.ChatMessagePayload.anime:type_name -> animeapp.v2.Anime
+	9,  // 9: animeapp.v2.ChatMessagePayload.buttons:type_name -> animeapp.v2.Button
+	8,  // 10: animeapp.v2.DigestPayload.animes:type_name -> animeapp.v2.Anime
+	8,  // 11: animeapp.v2.ListPayload.animes:type_name -> animeapp.v2.Anime
+	8,  // 12: animeapp.v2.AnswerQueryPayload.animes:type_name -> animeapp.v2.Anime
+	10, // 13: animeapp.v2.Anime.next_episode_at:type_name -> google.protobuf.Timestamp
+	14, // [14:14] is the sub-list for method output_type
+	14, // [14:14] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
+}
+
+func init() { file_proto_telegram_command_proto_init() }
+func file_proto_telegram_command_proto_init() {
+	if File_proto_telegram_command_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_proto_telegram_command_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Envelope); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_telegram_command_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ChatMessagePayload); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_telegram_command_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DigestPayload); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_telegram_command_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListPayload); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_telegram_command_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AnswerQueryPayload); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_telegram_command_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubscriptionPayload); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_telegram_command_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EditTextPayload); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_telegram_command_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AnswerCallbackPayload); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_telegram_command_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Anime); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_telegram_command_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Button); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_proto_telegram_command_proto_msgTypes[0].OneofWrappers = []interface{}{
+		(*Envelope_ChatMessage)(nil),
+		(*Envelope_Digest)(nil),
+		(*Envelope_List)(nil),
+		(*Envelope_AnswerQuery)(nil),
+		(*Envelope_Subscription)(nil),
+		(*Envelope_EditText)(nil),
+		(*Envelope_AnswerCallback)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_proto_telegram_command_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_telegram_command_proto_goTypes,
+		DependencyIndexes: file_proto_telegram_command_proto_depIdxs,
+		MessageInfos:      file_proto_telegram_command_proto_msgTypes,
+	}.Build()
+	File_proto_telegram_command_proto = out.File
+	file_proto_telegram_command_proto_rawDesc = nil
+	file_proto_telegram_command_proto_goTypes = nil
+	file_proto_telegram_command_proto_depIdxs = nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	commandpb "github.com/HDIOES/anime-app/proto"
+)
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative proto/telegram_command.proto
+
+//Encodings of outbound NATS messages, protobuf messages are described in proto/telegram_command.proto
+const (
+	jsonEncoding     = "json"
+	protobufEncoding = "protobuf"
+)
+
+//Content types passed in Content-Type NATS header
+const (
+	jsonContentType     = "application/json"
+	protobufContentType = "application/x-protobuf"
+)
+
+//marshalProto func encodes envelope as animeapp.v2.Envelope generated from proto/telegram_command.proto
+func marshalProto(envelope *Envelope) ([]byte, error) {
+	protoEnvelope := &commandpb.Envelope{
+		SchemaVersion: int32(envelope.SchemaVersion),
+		MessageId:     envelope.MessageID,
+		Timestamp:     timestamppb.New(envelope.Timestamp),
+		Type:          envelope.Type,
+	}
+	switch payload := envelope.Payload.(type) {
+	case *ChatMessagePayload:
+		chatMessage := &commandpb.ChatMessagePayload{
+			TelegramId: payload.TelegramID,
+			Text:       payload.Text,
+			Buttons:    protoButtons(payload.Buttons),
+		}
+		if payload.Anime != nil {
+			chatMessage.Anime = protoAnime(payload.Anime)
+		}
+		protoEnvelope.Payload = &commandpb.Envelope_ChatMessage{ChatMessage: chatMessage}
+	case *DigestPayload:
+		protoEnvelope.Payload = &commandpb.Envelope_Digest{Digest: &commandpb.DigestPayload{
+			TelegramId: payload.TelegramID,
+			Text:       payload.Text,
+			Animes:     protoAnimes(payload.Animes),
+		}}
+	case *ListPayload:
+		protoEnvelope.Payload = &commandpb.Envelope_List{List: &commandpb.ListPayload{
+			TelegramId:      payload.TelegramID,
+			Text:            payload.Text,
+			Animes:          protoAnimes(payload.Animes),
+			Page:            payload.Page,
+			HasNextPage:     payload.HasNextPage,
+			ChatId:          payload.ChatID,
+			MessageId:       payload.MessageID,
+			CallbackQueryId: payload.CallbackQueryID,
+		}}
+	case *AnswerQueryPayload:
+		protoEnvelope.Payload = &commandpb.Envelope_AnswerQuery{AnswerQuery: &commandpb.AnswerQueryPayload{
+			InlineQueryId: payload.InlineQueryID,
+			Animes:        protoAnimes(payload.Animes),
+			NextOffset:    payload.NextOffset,
+		}}
+	case *SubscriptionPayload:
+		protoEnvelope.Payload = &commandpb.Envelope_Subscription{Subscription: &commandpb.SubscriptionPayload{
+			ChatId:          payload.ChatID,
+			MessageId:       payload.MessageID,
+			CallbackQueryId: payload.CallbackQueryID,
+			AnimeId:         payload.AnimeID,
+		}}
+	case *EditTextPayload:
+		protoEnvelope.Payload = &commandpb.Envelope_EditText{EditText: &commandpb.EditTextPayload{
+			ChatId:          payload.ChatID,
+			MessageId:       payload.MessageID,
+			CallbackQueryId: payload.CallbackQueryID,
+			Text:            payload.Text,
+		}}
+	case *AnswerCallbackPayload:
+		protoEnvelope.Payload = &commandpb.Envelope_AnswerCallback{AnswerCallback: &commandpb.AnswerCallbackPayload{
+			CallbackQueryId: payload.CallbackQueryID,
+			Text:            payload.Text,
+		}}
+	default:
+		return nil, errors.Errorf("Message type %s has no protobuf payload", envelope.Type)
+	}
+	data, marshalErr := proto.Marshal(protoEnvelope)
+	if marshalErr != nil {
+		return nil, errors.WithStack(marshalErr)
+	}
+	return data, nil
+}
+
+func protoAnime(anime *AnimePayload) *commandpb.Anime {
+	protoAnime := &commandpb.Anime{
+		Id:           anime.ID,
+		Name:         anime.Name,
+		ThumbnailUrl: anime.ThumbnailURL,
+		Subscribed:   anime.Subscribed,
+		AirTime:      anime.AirTime,
+	}
+	if anime.NextEpisodeAt != nil {
+		protoAnime.NextEpisodeAt = timestamppb.New(*anime.NextEpisodeAt)
+	}
+	return protoAnime
+}
+
+func protoAnimes(animes []AnimePayload) []*commandpb.Anime {
+	protoAnimes := make([]*commandpb.Anime, 0, len(animes))
+	for i := range animes {
+		protoAnimes = append(protoAnimes, protoAnime(&animes[i]))
+	}
+	return protoAnimes
+}
+
+func protoButtons(buttons []ButtonPayload) []*commandpb.Button {
+	protoButtons := make([]*commandpb.Button, 0, len(buttons))
+	for _, button := range buttons {
+		protoButtons = append(protoButtons, &commandpb.Button{Text: button.Text, CallbackData: button.CallbackData})
+	}
+	return protoButtons
+}
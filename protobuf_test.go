@@ -0,0 +1,161 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	commandpb "github.com/HDIOES/anime-app/proto"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestMarshalProtoDecodesWithGeneratedEnvelope(t *testing.T) {
+	nextEpisodeAt := time.Date(2021, 4, 10, 15, 30, 0, 500, time.UTC)
+	inlineAnime := InlineAnime{
+		InternalID:           7,
+		AnimeName:            "Shingeki no Kyojin",
+		AnimeThumbnailPicURL: "https://shikimori.one/7.jpg",
+		UserHasSubscription:  true,
+		NextEpisodeAt:        &nextEpisodeAt,
+		AirTime:              "Sat 18:30 MSK",
+	}
+	anime := &commandpb.Anime{
+		Id:            7,
+		Name:          "Shingeki no Kyojin",
+		ThumbnailUrl:  "https://shikimori.one/7.jpg",
+		Subscribed:    true,
+		NextEpisodeAt: timestamppb.New(nextEpisodeAt),
+		AirTime:       "Sat 18:30 MSK",
+	}
+	tests := []struct {
+		name     string
+		message  TelegramCommandMessage
+		expected *commandpb.Envelope
+	}{
+		{
+			name: startType,
+			message: TelegramCommandMessage{
+				Type:          startType,
+				TelegramID:    42,
+				Text:          "Привет",
+				InlineButtons: []InlineButton{{Text: "English", CallbackData: "language:en"}},
+			},
+			expected: &commandpb.Envelope{Payload: &commandpb.Envelope_ChatMessage{ChatMessage: &commandpb.ChatMessagePayload{
+				TelegramId: 42,
+				Text:       "Привет",
+				Buttons:    []*commandpb.Button{{Text: "English", CallbackData: "language:en"}},
+			}}},
+		},
+		{
+			name:    notificationType,
+			message: TelegramCommandMessage{Type: notificationType, TelegramID: 42, Text: "New episode", InlineAnime: &inlineAnime},
+			expected: &commandpb.Envelope{Payload: &commandpb.Envelope_ChatMessage{ChatMessage: &commandpb.ChatMessagePayload{
+				TelegramId: 42,
+				Text:       "New episode",
+				Anime:      anime,
+			}}},
+		},
+		{
+			name:    digestType,
+			message: TelegramCommandMessage{Type: digestType, TelegramID: 42, Text: "Digest", InlineAnimes: []InlineAnime{inlineAnime}},
+			expected: &commandpb.Envelope{Payload: &commandpb.Envelope_Digest{Digest: &commandpb.DigestPayload{
+				TelegramId: 42,
+				Text:       "Digest",
+				Animes:     []*commandpb.Anime{anime},
+			}}},
+		},
+		{
+			name: listType,
+			message: TelegramCommandMessage{
+				Type:            listType,
+				TelegramID:      42,
+				Text:            "Subscriptions",
+				InlineAnimes:    []InlineAnime{inlineAnime},
+				Page:            2,
+				HasNextPage:     true,
+				ChatID:          -100,
+				MessageID:       5,
+				CallbackQueryID: "query",
+			},
+			expected: &commandpb.Envelope{Payload: &commandpb.Envelope_List{List: &commandpb.ListPayload{
+				TelegramId:      42,
+				Text:            "Subscriptions",
+				Animes:          []*commandpb.Anime{anime},
+				Page:            2,
+				HasNextPage:     true,
+				ChatId:          -100,
+				MessageId:       5,
+				CallbackQueryId: "query",
+			}}},
+		},
+		{
+			name:    answerQueryType,
+			message: TelegramCommandMessage{Type: answerQueryType, InlineQueryID: "inline", InlineAnimes: []InlineAnime{inlineAnime}, NextOffset: "50"},
+			expected: &commandpb.Envelope{Payload: &commandpb.Envelope_AnswerQuery{AnswerQuery: &commandpb.AnswerQueryPayload{
+				InlineQueryId: "inline",
+				Animes:        []*commandpb.Anime{anime},
+				NextOffset:    "50",
+			}}},
+		},
+		{
+			name:    unsubscribeType,
+			message: TelegramCommandMessage{Type: unsubscribeType, ChatID: 42, MessageID: 5, CallbackQueryID: "query", InternalAnimeID: 7},
+			expected: &commandpb.Envelope{Payload: &commandpb.Envelope_Subscription{Subscription: &commandpb.SubscriptionPayload{
+				ChatId:          42,
+				MessageId:       5,
+				CallbackQueryId: "query",
+				AnimeId:         7,
+			}}},
+		},
+		{
+			name:    editTextType,
+			message: TelegramCommandMessage{Type: editTextType, ChatID: 42, MessageID: 5, CallbackQueryID: "query", Text: "Done"},
+			expected: &commandpb.Envelope{Payload: &commandpb.Envelope_EditText{EditText: &commandpb.EditTextPayload{
+				ChatId:          42,
+				MessageId:       5,
+				CallbackQueryId: "query",
+				Text:            "Done",
+			}}},
+		},
+		{
+			name:    answerCallbackType,
+			message: TelegramCommandMessage{Type: answerCallbackType, CallbackQueryID: "query", Text: "Done"},
+			expected: &commandpb.Envelope{Payload: &commandpb.Envelope_AnswerCallback{AnswerCallback: &commandpb.AnswerCallbackPayload{
+				CallbackQueryId: "query",
+				Text:            "Done",
+			}}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			message, encodeErr := encodeNtsMessage(&Settings{MessageEncoding: protobufEncoding}, &test.message)
+			if encodeErr != nil {
+				t.Fatal(encodeErr)
+			}
+			if message.ContentType != protobufContentType {
+				t.Fatalf("content type is %q, want %q", message.ContentType, protobufContentType)
+			}
+			decoded := &commandpb.Envelope{}
+			if err := proto.Unmarshal(message.Data, decoded); err != nil {
+				t.Fatal(err)
+			}
+			if decoded.SchemaVersion != envelopeSchemaVersion || decoded.Type != test.message.Type {
+				t.Fatalf("envelope header is %d %q", decoded.SchemaVersion, decoded.Type)
+			}
+			if len(decoded.MessageId) != 36 || decoded.Timestamp == nil {
+				t.Fatalf("envelope has no message id or timestamp: %v", decoded)
+			}
+			if message.MessageID != decoded.MessageId {
+				t.Fatalf("message id is %q, envelope has %q", message.MessageID, decoded.MessageId)
+			}
+			expected := test.expected
+			expected.SchemaVersion = envelopeSchemaVersion
+			expected.MessageId = decoded.MessageId
+			expected.Timestamp = decoded.Timestamp
+			expected.Type = test.message.Type
+			if !proto.Equal(decoded, expected) {
+				t.Fatalf("decoded envelope is %v, want %v", decoded, expected)
+			}
+		})
+	}
+}
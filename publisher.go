@@ -0,0 +1,89 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+)
+
+const contentTypeHeader = "Content-Type"
+
+//maxPublishRetryTime bounds time Publish waits between JetStream retries, because it is called while handling updates.
+//Outbox relay retries messages which still fail later
+const maxPublishRetryTime = 2 * time.Second
+
+//NatsPublisher struct publishes messages with core NATS or, when JetStream is enabled in Settings,
+//to JetStream stream waiting for PubAck of every message
+type NatsPublisher struct {
+	natsConnection *nats.Conn
+	jetStream      nats.JetStreamContext
+	settings       *Settings
+}
+
+//NewNatsPublisher func creates JetStream stream with NatsSubject when JetStream is enabled and the stream does not exist
+func NewNatsPublisher(natsConnection *nats.Conn, settings *Settings) (*NatsPublisher, error) {
+	publisher := &NatsPublisher{
+		natsConnection: natsConnection,
+		settings:       settings,
+	}
+	if !settings.JetStream {
+		return publisher, nil
+	}
+	jetStream, jsErr := natsConnection.JetStream()
+	if jsErr != nil {
+		return nil, errors.WithStack(jsErr)
+	}
+	if _, infoErr := jetStream.StreamInfo(settings.JetStreamName); infoErr == nats.ErrStreamNotFound {
+		streamConfig := &nats.StreamConfig{
+			Name:     settings.JetStreamName,
+			Subjects: []string{settings.NatsSubject},
+		}
+		if _, addErr := jetStream.AddStream(streamConfig); addErr != nil {
+			return nil, errors.WithStack(addErr)
+		}
+		log.Printf("JetStream stream %s created\n", settings.JetStreamName)
+	} else if infoErr != nil {
+		return nil, errors.WithStack(infoErr)
+	}
+	publisher.jetStream = jetStream
+	return publisher, nil
+}
+
+//Publish func publishes data to subject with Content-Type header. In JetStream mode publish is retried JetStreamRetries times
+//with exponential backoff starting from JetStreamBackoff milliseconds while total backoff fits into maxPublishRetryTime,
+//failed ack is returned as TransientError. Non-empty msgID is sent as Nats-Msg-Id, so the stream stores a retried message once
+func (np *NatsPublisher) Publish(subject string, data []byte, contentType string, msgID string) error {
+	msg := nats.NewMsg(subject)
+	msg.Header.Set(contentTypeHeader, contentType)
+	msg.Data = data
+	if np.jetStream == nil {
+		if publishErr := np.natsConnection.PublishMsg(msg); publishErr != nil {
+			return newTransientError(publishErr)
+		}
+		return nil
+	}
+	var opts []nats.PubOpt
+	if msgID != "" {
+		opts = append(opts, nats.MsgId(msgID))
+	}
+	backoff := time.Duration(np.settings.JetStreamBackoff) * time.Millisecond
+	var waited time.Duration
+	var ackErr error
+	for attempt := 0; attempt <= np.settings.JetStreamRetries; attempt++ {
+		if attempt > 0 {
+			if waited+backoff > maxPublishRetryTime {
+				break
+			}
+			time.Sleep(backoff)
+			waited += backoff
+			backoff *= 2
+		}
+		if _, ackErr = np.jetStream.PublishMsg(msg, opts...); ackErr == nil {
+			return nil
+		}
+		log.Printf("JetStream publish attempt %d to %s failed: %v\n", attempt+1, subject, ackErr)
+	}
+	return newTransientError(ackErr)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	natsserver "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+)
+
+func runJetStreamServer(t *testing.T) *server.Server {
+	opts := natsserver.DefaultTestOptions
+	opts.Port = -1
+	opts.JetStream = true
+	opts.StoreDir = t.TempDir()
+	natsServer := natsserver.RunServer(&opts)
+	t.Cleanup(natsServer.Shutdown)
+	return natsServer
+}
+
+func newTestPublisher(t *testing.T, retries int) (*NatsPublisher, nats.JetStreamContext) {
+	natsServer := runJetStreamServer(t)
+	natsConnection, connectErr := nats.Connect(natsServer.ClientURL())
+	if connectErr != nil {
+		t.Fatal(connectErr)
+	}
+	t.Cleanup(natsConnection.Close)
+	settings := &Settings{
+		NatsSubject:      "telegramCommandMessages",
+		JetStream:        true,
+		JetStreamName:    "TELEGRAM_COMMANDS",
+		JetStreamRetries: retries,
+		JetStreamBackoff: 50,
+	}
+	publisher, publisherErr := NewNatsPublisher(natsConnection, settings)
+	if publisherErr != nil {
+		t.Fatal(publisherErr)
+	}
+	jetStream, jsErr := natsConnection.JetStream()
+	if jsErr != nil {
+		t.Fatal(jsErr)
+	}
+	return publisher, jetStream
+}
+
+func TestPublishIsAcknowledgedByStream(t *testing.T) {
+	publisher, jetStream := newTestPublisher(t, 0)
+	if err := publisher.Publish("telegramCommandMessages", []byte(`{"type":"startType"}`), jsonContentType, ""); err != nil {
+		t.Fatal(err)
+	}
+	streamInfo, infoErr := jetStream.StreamInfo("TELEGRAM_COMMANDS")
+	if infoErr != nil {
+		t.Fatal(infoErr)
+	}
+	if streamInfo.State.Msgs != 1 {
+		t.Fatalf("stream has %d messages, want 1", streamInfo.State.Msgs)
+	}
+	storedMsg, getErr := jetStream.GetMsg("TELEGRAM_COMMANDS", 1)
+	if getErr != nil {
+		t.Fatal(getErr)
+	}
+	if contentType := storedMsg.Header.Get(contentTypeHeader); contentType != jsonContentType {
+		t.Fatalf("Content-Type is %q, want %q", contentType, jsonContentType)
+	}
+}
+
+func TestPublishRetriesUntilStreamIsBack(t *testing.T) {
+	publisher, jetStream := newTestPublisher(t, 3)
+	if err := jetStream.DeleteStream("TELEGRAM_COMMANDS"); err != nil {
+		t.Fatal(err)
+	}
+	restored := make(chan error, 1)
+	go func() {
+		time.Sleep(70 * time.Millisecond)
+		_, addErr := jetStream.AddStream(&nats.StreamConfig{Name: "TELEGRAM_COMMANDS", Subjects: []string{"telegramCommandMessages"}})
+		restored <- addErr
+	}()
+	if err := publisher.Publish("telegramCommandMessages", []byte(`{}`), jsonContentType, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-restored; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPublishWithoutStreamIsTransientError(t *testing.T) {
+	publisher, jetStream := newTestPublisher(t, 2)
+	if err := jetStream.DeleteStream("TELEGRAM_COMMANDS"); err != nil {
+		t.Fatal(err)
+	}
+	err := publisher.Publish("telegramCommandMessages", []byte(`{}`), jsonContentType, "")
+	if err == nil {
+		t.Fatal("Publish succeeded without stream")
+	}
+	if code := statusCode(err); code != http.StatusServiceUnavailable {
+		t.Fatalf("status code is %d, want %d", code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestPublishWithSameMessageIDIsStoredOnce(t *testing.T) {
+	publisher, jetStream := newTestPublisher(t, 0)
+	for i := 0; i < 2; i++ {
+		if err := publisher.Publish("telegramCommandMessages", []byte(`{}`), jsonContentType, "3f2b8c1e-7d4a-4b6e-9c0f-1a2b3c4d5e6f"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := publisher.Publish("telegramCommandMessages", []byte(`{}`), jsonContentType, "9e8d7c6b-5a4f-4e3d-8c2b-1a0f9e8d7c6b"); err != nil {
+		t.Fatal(err)
+	}
+	streamInfo, infoErr := jetStream.StreamInfo("TELEGRAM_COMMANDS")
+	if infoErr != nil {
+		t.Fatal(infoErr)
+	}
+	if streamInfo.State.Msgs != 2 {
+		t.Fatalf("stream has %d messages, want 2", streamInfo.State.Msgs)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"time"
+
+	"github.com/HDIOES/anime-app/dao"
+)
+
+//quietUntil func returns end of the quiet hours window when now falls into it in the user's timezone.
+//Window may pass midnight, e.g. from 23 to 8
+func quietUntil(preferences *dao.PreferencesDTO, now time.Time, location *time.Location) (time.Time, bool) {
+	if preferences.QuietFrom == preferences.QuietTo {
+		return time.Time{}, false
+	}
+	local := now.In(location)
+	hour := local.Hour()
+	var quiet bool
+	if preferences.QuietFrom < preferences.QuietTo {
+		quiet = hour >= preferences.QuietFrom && hour < preferences.QuietTo
+	} else {
+		quiet = hour >= preferences.QuietFrom || hour < preferences.QuietTo
+	}
+	if !quiet {
+		return time.Time{}, false
+	}
+	end := time.Date(local.Year(), local.Month(), local.Day(), preferences.QuietTo, 0, 0, 0, location)
+	if !end.After(local) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end, true
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/HDIOES/anime-app/dao"
+)
+
+func TestQuietUntil(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	tests := []struct {
+		name     string
+		from     int
+		to       int
+		now      time.Time
+		location *time.Location
+		quiet    bool
+		until    time.Time
+	}{
+		{"window past midnight before it", 23, 7, time.Date(2024, 1, 1, 22, 59, 0, 0, time.UTC), time.UTC, false, time.Time{}},
+		{"window past midnight before midnight", 23, 7, time.Date(2024, 1, 1, 23, 30, 0, 0, time.UTC), time.UTC, true, time.Date(2024, 1, 2, 7, 0, 0, 0, time.UTC)},
+		{"window past midnight after midnight", 23, 7, time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC), time.UTC, true, time.Date(2024, 1, 2, 7, 0, 0, 0, time.UTC)},
+		{"window past midnight at its end", 23, 7, time.Date(2024, 1, 2, 7, 0, 0, 0, time.UTC), time.UTC, false, time.Time{}},
+		{"window past midnight at noon", 23, 7, time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC), time.UTC, false, time.Time{}},
+		{"window within day", 1, 5, time.Date(2024, 1, 1, 4, 59, 0, 0, time.UTC), time.UTC, true, time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC)},
+		{"window within day after it", 1, 5, time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC), time.UTC, false, time.Time{}},
+		{"from equals to", 7, 7, time.Date(2024, 1, 1, 7, 30, 0, 0, time.UTC), time.UTC, false, time.Time{}},
+		{"from equals to at midnight", 0, 0, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.UTC, false, time.Time{}},
+		{"window in user timezone", 23, 7, time.Date(2024, 1, 1, 21, 0, 0, 0, time.UTC), moscow, true, time.Date(2024, 1, 2, 4, 0, 0, 0, time.UTC)},
+		{"window in user timezone outside it", 23, 7, time.Date(2024, 1, 1, 19, 0, 0, 0, time.UTC), moscow, false, time.Time{}},
+	}
+	for _, test := range tests {
+		preferences := &dao.PreferencesDTO{QuietFrom: test.from, QuietTo: test.to}
+		until, quiet := quietUntil(preferences, test.now, test.location)
+		if quiet != test.quiet || !until.Equal(test.until) {
+			t.Errorf("%s: quiet until %v is %t, want %v is %t", test.name, until, quiet, test.until, test.quiet)
+		}
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HDIOES/anime-app/dao"
+)
+
+//idleChatTTL is how long limiter state of a chat without deliveries is kept
+const idleChatTTL = time.Minute
+
+//maxGlobalWait is the longest wait for the global limit, longer queue postpones the message
+const maxGlobalWait = time.Second
+
+//RetryAfterError is returned by sender when Telegram asks to repeat the request after RetryAfter
+type RetryAfterError struct {
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (re *RetryAfterError) Error() string {
+	return re.Err.Error()
+}
+
+//Unwrap func
+func (re *RetryAfterError) Unwrap() error {
+	return re.Err
+}
+
+//tokenBucket struct hands out reservations, tokens go negative when reservations are made ahead,
+//so concurrent senders are served in order of their reservations
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate int, now time.Time) *tokenBucket {
+	return &tokenBucket{rate: float64(rate), burst: float64(rate), tokens: float64(rate), last: now}
+}
+
+//reserve func takes one token and returns time when it becomes available
+func (tb *tokenBucket) reserve(now time.Time) time.Time {
+	tb.tokens = math.Min(tb.burst, tb.tokens+now.Sub(tb.last).Seconds()*tb.rate)
+	tb.last = now
+	tb.tokens--
+	if tb.tokens >= 0 {
+		return now
+	}
+	return now.Add(time.Duration(-tb.tokens / tb.rate * float64(time.Second)))
+}
+
+//cancel func returns token of reservation which will not be used
+func (tb *tokenBucket) cancel() {
+	tb.tokens++
+}
+
+func (tb *tokenBucket) full(now time.Time) bool {
+	return tb.tokens+now.Sub(tb.last).Seconds()*tb.rate >= tb.burst
+}
+
+//Stores of the global limit, memory store limits each replica separately
+const (
+	memoryRateLimitStore   = "memory"
+	postgresRateLimitStore = "postgres"
+)
+
+//globalRateLimitName is the name of the global bucket in RATE_LIMITS table
+const globalRateLimitName = "global"
+
+//globalLimiter interface is GlobalRateLimit bucket shared by all deliveries
+type globalLimiter interface {
+	//reserve takes one token and returns time when it becomes available
+	reserve(now time.Time) (time.Time, error)
+	//cancel returns token of reservation which will not be used
+	cancel() error
+	//backOff pauses all deliveries for retryAfter
+	backOff(now time.Time, retryAfter time.Duration) error
+}
+
+//memoryGlobalLimiter struct limits deliveries of the current process only
+type memoryGlobalLimiter struct {
+	bucket       *tokenBucket
+	blockedUntil time.Time
+}
+
+func newMemoryGlobalLimiter(rate int) *memoryGlobalLimiter {
+	return &memoryGlobalLimiter{bucket: newTokenBucket(rate, time.Now())}
+}
+
+func (mgl *memoryGlobalLimiter) reserve(now time.Time) (time.Time, error) {
+	at := mgl.bucket.reserve(now)
+	if mgl.blockedUntil.After(at) {
+		at = mgl.blockedUntil
+	}
+	return at, nil
+}
+
+func (mgl *memoryGlobalLimiter) cancel() error {
+	mgl.bucket.cancel()
+	return nil
+}
+
+func (mgl *memoryGlobalLimiter) backOff(now time.Time, retryAfter time.Duration) error {
+	mgl.blockedUntil = now.Add(retryAfter)
+	return nil
+}
+
+//postgresGlobalLimiter struct keeps the bucket in RATE_LIMITS table, so replicas share GlobalRateLimit
+type postgresGlobalLimiter struct {
+	rldao *dao.RateLimitDAO
+	rate  int
+}
+
+func (pgl *postgresGlobalLimiter) reserve(now time.Time) (time.Time, error) {
+	wait, err := pgl.rldao.Reserve(globalRateLimitName, pgl.rate)
+	if err != nil {
+		return now, err
+	}
+	return now.Add(wait), nil
+}
+
+func (pgl *postgresGlobalLimiter) cancel() error {
+	return pgl.rldao.Cancel(globalRateLimitName)
+}
+
+func (pgl *postgresGlobalLimiter) backOff(now time.Time, retryAfter time.Duration) error {
+	return pgl.rldao.Block(globalRateLimitName, retryAfter)
+}
+
+type chatLimiter struct {
+	bucket       *tokenBucket
+	blockedUntil time.Time
+}
+
+//RateLimitedSender struct is a delivery queue within GlobalRateLimit and ChatRateLimit messages per second.
+//Send puts message to outbox and OutboxRelay delivers it with SendEncoded. Message to a chat which is over its limit
+//or got retry_after from Telegram is postponed, message which is not delivered within DeliveryDeadline seconds
+//goes to dead-letter table. ChatRateLimit is kept in memory, so it is enforced per replica
+type RateLimitedSender struct {
+	sender    MessageSender
+	odao      *dao.OutboxDAO
+	settings  *Settings
+	queued    chan struct{}
+	mutex     sync.Mutex
+	global    globalLimiter
+	chats     map[int64]*chatLimiter
+	lastSweep time.Time
+}
+
+//NewRateLimitedSender func
+func NewRateLimitedSender(sender MessageSender, odao *dao.OutboxDAO, global globalLimiter, settings *Settings) *RateLimitedSender {
+	return &RateLimitedSender{
+		sender:    sender,
+		odao:      odao,
+		settings:  settings,
+		queued:    make(chan struct{}, 1),
+		global:    global,
+		chats:     make(map[int64]*chatLimiter),
+		lastSweep: time.Now(),
+	}
+}
+
+//rateLimited func wraps sender with RateLimitedSender unless GlobalRateLimit is zero. Global limit is kept
+//in Postgres when RateLimitStore is postgres or when it is not set and updates are consumed from NATS by several replicas
+func rateLimited(sender MessageSender, odao *dao.OutboxDAO, rldao *dao.RateLimitDAO, settings *Settings) MessageSender {
+	if settings.GlobalRateLimit <= 0 {
+		return sender
+	}
+	switch settings.RateLimitStore {
+	case postgresRateLimitStore:
+	case memoryRateLimitStore:
+		return NewRateLimitedSender(sender, odao, newMemoryGlobalLimiter(settings.GlobalRateLimit), settings)
+	case "":
+		if settings.InboundSubject == "" {
+			return NewRateLimitedSender(sender, odao, newMemoryGlobalLimiter(settings.GlobalRateLimit), settings)
+		}
+	default:
+		log.Panicln("Unknown rate limit store: ", settings.RateLimitStore)
+	}
+	return NewRateLimitedSender(sender, odao, &postgresGlobalLimiter{rldao: rldao, rate: settings.GlobalRateLimit}, settings)
+}
+
+//Send func puts message to outbox and wakes up the relay, so the caller never waits for its turn
+func (rs *RateLimitedSender) Send(ntsMessage *TelegramCommandMessage) error {
+	message, encodeErr := rs.sender.Encode(ntsMessage)
+	if encodeErr != nil {
+		return encodeErr
+	}
+	if err := rs.odao.Enqueue(message); err != nil {
+		return newTransientError(err)
+	}
+	select {
+	case rs.queued <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+//Queued func returns channel which receives a value when Send puts message to outbox
+func (rs *RateLimitedSender) Queued() <-chan struct{} {
+	return rs.queued
+}
+
+//Encode func
+func (rs *RateLimitedSender) Encode(ntsMessage *TelegramCommandMessage) (*dao.OutboxMessage, error) {
+	return rs.sender.Encode(ntsMessage)
+}
+
+//SendEncoded func is called by the relay. Message is sent when its chat is ready and the global queue
+//is not longer than maxGlobalWait, otherwise it is postponed. Messages without chat are limited globally only
+func (rs *RateLimitedSender) SendEncoded(message *dao.OutboxMessage) error {
+	deadline := time.Duration(rs.settings.DeliveryDeadline) * time.Second
+	if deadline > 0 && !message.CreatedAt.IsZero() && time.Since(message.CreatedAt) > deadline {
+		err := errors.Errorf("Message to chat %d moved to dead letters", message.ChatID)
+		HandleError(err)
+		return &dao.DeadLetterError{Reason: "delivery deadline exceeded", Err: err}
+	}
+	at, ok, reserveErr := rs.reserve(message.ChatID)
+	if reserveErr != nil {
+		return newTransientError(reserveErr)
+	}
+	if !ok {
+		return &dao.PostponeError{RetryAt: at, Err: errors.Errorf("Chat %d is rate limited", message.ChatID)}
+	}
+	time.Sleep(time.Until(at))
+	err := rs.sender.SendEncoded(message)
+	var retryAfterErr *RetryAfterError
+	if !errors.As(err, &retryAfterErr) {
+		return err
+	}
+	HandleError(err)
+	rs.backOff(message.ChatID, retryAfterErr.RetryAfter)
+	return &dao.PostponeError{RetryAt: time.Now().Add(retryAfterErr.RetryAfter), Err: err}
+}
+
+//reserve func returns time when message may be sent. Reservation is cancelled and false is returned
+//when the chat is not ready yet or the message would wait for the global limit longer than maxGlobalWait
+func (rs *RateLimitedSender) reserve(chatID int64) (time.Time, bool, error) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	now := time.Now()
+	rs.sweep(now)
+	at, globalErr := rs.global.reserve(now)
+	if globalErr != nil {
+		return now, false, globalErr
+	}
+	ready := !at.After(now.Add(maxGlobalWait))
+	var chat *chatLimiter
+	if chatID != 0 && rs.settings.ChatRateLimit > 0 {
+		chat = rs.chats[chatID]
+		if chat == nil {
+			chat = &chatLimiter{bucket: newTokenBucket(rs.settings.ChatRateLimit, now)}
+			rs.chats[chatID] = chat
+		}
+		chatAt := chat.bucket.reserve(now)
+		if chat.blockedUntil.After(chatAt) {
+			chatAt = chat.blockedUntil
+		}
+		if chatAt.After(now) {
+			ready = false
+		}
+		if chatAt.After(at) {
+			at = chatAt
+		}
+	}
+	if !ready {
+		if chat != nil {
+			chat.bucket.cancel()
+		}
+		return at, false, rs.global.cancel()
+	}
+	return at, true, nil
+}
+
+func (rs *RateLimitedSender) backOff(chatID int64, retryAfter time.Duration) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	now := time.Now()
+	if chat := rs.chats[chatID]; chat != nil {
+		chat.blockedUntil = now.Add(retryAfter)
+	} else if err := rs.global.backOff(now, retryAfter); err != nil {
+		HandleError(err)
+	}
+}
+
+//sweep func forgets chats whose bucket is full and which are not paused
+func (rs *RateLimitedSender) sweep(now time.Time) {
+	if now.Sub(rs.lastSweep) < idleChatTTL {
+		return
+	}
+	rs.lastSweep = now
+	for chatID, chat := range rs.chats {
+		if chat.bucket.full(now) && chat.blockedUntil.Before(now) {
+			delete(rs.chats, chatID)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HDIOES/anime-app/dao"
+)
+
+type recordingSender struct {
+	sent []*dao.OutboxMessage
+	err  error
+}
+
+func (rs *recordingSender) Send(ntsMessage *TelegramCommandMessage) error {
+	return errors.New("Send is not expected")
+}
+
+func (rs *recordingSender) Encode(ntsMessage *TelegramCommandMessage) (*dao.OutboxMessage, error) {
+	return &dao.OutboxMessage{ChatID: messageChatID(ntsMessage), ContentType: jsonContentType}, nil
+}
+
+func (rs *recordingSender) SendEncoded(message *dao.OutboxMessage) error {
+	rs.sent = append(rs.sent, message)
+	return rs.err
+}
+
+func newTestRateLimitedSender(sender MessageSender) *RateLimitedSender {
+	return NewRateLimitedSender(sender, nil, newMemoryGlobalLimiter(30), &Settings{GlobalRateLimit: 30, ChatRateLimit: 1, DeliveryDeadline: 60})
+}
+
+func TestRateLimitedSenderPostponesBusyChat(t *testing.T) {
+	sender := &recordingSender{}
+	rs := newTestRateLimitedSender(sender)
+	if err := rs.SendEncoded(&dao.OutboxMessage{ChatID: 42, CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	err := rs.SendEncoded(&dao.OutboxMessage{ChatID: 42, CreatedAt: time.Now()})
+	var postponeErr *dao.PostponeError
+	if !errors.As(err, &postponeErr) {
+		t.Fatalf("second message to the chat returned %v, want PostponeError", err)
+	}
+	if wait := time.Until(postponeErr.RetryAt); wait <= 0 || wait > time.Second {
+		t.Fatalf("message is postponed for %v, want up to one second", wait)
+	}
+	if err := rs.SendEncoded(&dao.OutboxMessage{ChatID: 43, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("message to another chat returned %v", err)
+	}
+	if len(sender.sent) != 2 {
+		t.Fatalf("%d messages sent, want 2", len(sender.sent))
+	}
+}
+
+func TestRateLimitedSenderBacksOffOnRetryAfter(t *testing.T) {
+	sender := &recordingSender{err: &RetryAfterError{RetryAfter: 5 * time.Second, Err: errors.New("Too Many Requests")}}
+	rs := newTestRateLimitedSender(sender)
+	err := rs.SendEncoded(&dao.OutboxMessage{ChatID: 42, CreatedAt: time.Now()})
+	var postponeErr *dao.PostponeError
+	if !errors.As(err, &postponeErr) {
+		t.Fatalf("retry_after returned %v, want PostponeError", err)
+	}
+	if wait := time.Until(postponeErr.RetryAt); wait < 4*time.Second {
+		t.Fatalf("message is postponed for %v, want retry_after", wait)
+	}
+	if _, ok, _ := rs.reserve(42); ok {
+		t.Fatal("chat is ready before retry_after has passed")
+	}
+}
+
+func TestRateLimitedSenderDeadLettersExpiredMessage(t *testing.T) {
+	sender := &recordingSender{}
+	rs := newTestRateLimitedSender(sender)
+	err := rs.SendEncoded(&dao.OutboxMessage{ChatID: 42, CreatedAt: time.Now().Add(-time.Hour)})
+	var deadLetterErr *dao.DeadLetterError
+	if !errors.As(err, &deadLetterErr) {
+		t.Fatalf("expired message returned %v, want DeadLetterError", err)
+	}
+	if len(sender.sent) != 0 {
+		t.Fatal("expired message was sent")
+	}
+}
+
+func TestRateLimitedSharesGlobalLimitInConsumerMode(t *testing.T) {
+	cases := []struct {
+		name     string
+		settings Settings
+		postgres bool
+	}{
+		{"webhook", Settings{GlobalRateLimit: 30}, false},
+		{"consumer", Settings{GlobalRateLimit: 30, InboundSubject: "updates"}, true},
+		{"memory in consumer mode", Settings{GlobalRateLimit: 30, InboundSubject: "updates", RateLimitStore: memoryRateLimitStore}, false},
+		{"postgres", Settings{GlobalRateLimit: 30, RateLimitStore: postgresRateLimitStore}, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			settings := c.settings
+			rs, ok := rateLimited(&recordingSender{}, nil, &dao.RateLimitDAO{}, &settings).(*RateLimitedSender)
+			if !ok {
+				t.Fatal("sender is not rate limited")
+			}
+			if _, postgres := rs.global.(*postgresGlobalLimiter); postgres != c.postgres {
+				t.Fatalf("global limit is kept in postgres: %v, expected %v", postgres, c.postgres)
+			}
+		})
+	}
+}
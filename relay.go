@@ -0,0 +1,83 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/HDIOES/anime-app/dao"
+)
+
+//deliveredOutboxTTL is how long delivered outbox messages are kept
+const deliveredOutboxTTL = 24 * time.Hour
+
+//OutboxRelay struct sends messages written to outbox together with subscription changes
+type OutboxRelay struct {
+	odao        *dao.OutboxDAO
+	udao        *dao.UserDAO
+	sender      MessageSender
+	settings    *Settings
+	lastCleanup time.Time
+}
+
+//queueSender interface is implemented by senders which put messages to outbox instead of sending them
+type queueSender interface {
+	Queued() <-chan struct{}
+}
+
+//Run func relays pending outbox messages every OutboxInterval seconds and whenever queueSender puts a message to outbox
+func (r *OutboxRelay) Run() {
+	interval := time.Duration(r.settings.OutboxInterval) * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var queued <-chan struct{}
+	if sender, ok := r.sender.(queueSender); ok {
+		queued = sender.Queued()
+	}
+	for {
+		select {
+		case <-ticker.C:
+		case <-queued:
+		}
+		count, err := r.odao.Relay(r.publish, interval)
+		if count > 0 {
+			log.Printf("%d outbox messages relayed\n", count)
+		}
+		if err != nil {
+			HandleError(err)
+		}
+		if time.Since(r.lastCleanup) > time.Hour {
+			if err := r.odao.DeleteDelivered(time.Now().Add(-deliveredOutboxTTL)); err != nil {
+				HandleError(err)
+			}
+			r.lastCleanup = time.Now()
+		}
+	}
+}
+
+//publish func sends message to its recipient. Only transient failures are retried by outbox, any other failure
+//is permanent for this recipient and the message is moved to dead letters. Chat of the user who blocked the bot
+//is marked blocked, so the user gets no more notifications
+func (r *OutboxRelay) publish(message *dao.OutboxMessage) error {
+	err := r.sender.SendEncoded(message)
+	if err == nil {
+		return nil
+	}
+	var postponeErr *dao.PostponeError
+	var deadLetterErr *dao.DeadLetterError
+	var transientErr *TransientError
+	if errors.As(err, &postponeErr) || errors.As(err, &deadLetterErr) || errors.As(err, &transientErr) {
+		return err
+	}
+	HandleError(err)
+	var botAPIErr *BotAPIError
+	if errors.As(err, &botAPIErr) && botAPIErr.ErrorCode == http.StatusForbidden && message.ChatID > 0 {
+		if blockErr := r.udao.SetBlocked(strconv.FormatInt(message.ChatID, 10), true); blockErr != nil {
+			return blockErr
+		}
+	}
+	return &dao.DeadLetterError{Reason: err.Error(), Err: err}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"github.com/HDIOES/anime-app/dao"
+)
+
+//Sender modes, nats publishes messages for external service calling Telegram, telegram calls Bot API directly
+const (
+	natsSenderMode     = "nats"
+	telegramSenderMode = "telegram"
+)
+
+//MessageSender interface delivers TelegramCommandMessage to the user
+type MessageSender interface {
+	Send(ntsMessage *TelegramCommandMessage) error
+	//Encode and SendEncoded are used by outbox, which keeps message between transaction commit and delivery
+	Encode(ntsMessage *TelegramCommandMessage) (*dao.OutboxMessage, error)
+	SendEncoded(message *dao.OutboxMessage) error
+}
+
+//NatsSender struct publishes messages to NatsSubject encoded according to Settings
+type NatsSender struct {
+	publisher *NatsPublisher
+	settings  *Settings
+}
+
+//Send func
+func (ns *NatsSender) Send(ntsMessage *TelegramCommandMessage) error {
+	message, err := ns.Encode(ntsMessage)
+	if err != nil {
+		return err
+	}
+	return ns.SendEncoded(message)
+}
+
+//Encode func
+func (ns *NatsSender) Encode(ntsMessage *TelegramCommandMessage) (*dao.OutboxMessage, error) {
+	message, err := encodeNtsMessage(ns.settings, ntsMessage)
+	if err != nil {
+		return nil, err
+	}
+	message.ChatID = messageChatID(ntsMessage)
+	return message, nil
+}
+
+//SendEncoded func
+func (ns *NatsSender) SendEncoded(message *dao.OutboxMessage) error {
+	return ns.publisher.Publish(ns.settings.NatsSubject, message.Data, message.ContentType, message.MessageID)
+}
+
+//messageChatID func returns chat of the message or zero for inline query and callback query answers
+func messageChatID(ntsMessage *TelegramCommandMessage) int64 {
+	switch ntsMessage.Type {
+	case answerQueryType, answerCallbackType:
+		return 0
+	}
+	if ntsMessage.ChatID != 0 {
+		return ntsMessage.ChatID
+	}
+	return ntsMessage.TelegramID
+}
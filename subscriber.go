@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+)
+
+//UpdatesSubscriber struct receives updates fanned out by gateway over NATS and feeds them to UpdateDispatcher.
+//Replicas subscribe in the same queue group, so every update is handled by one of them
+type UpdatesSubscriber struct {
+	dispatcher     UpdateDispatcher
+	natsConnection *nats.Conn
+	settings       *Settings
+}
+
+//Subscribe func subscribes to InboundSubject in InboundQueue group
+func (us *UpdatesSubscriber) Subscribe() (*nats.Subscription, error) {
+	subscription, err := us.natsConnection.QueueSubscribe(us.settings.InboundSubject, us.settings.InboundQueue, us.handleMsg)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	log.Printf("Subscribed to %s in queue group %s\n", us.settings.InboundSubject, us.settings.InboundQueue)
+	return subscription, nil
+}
+
+//handleMsg func responds with HTTP-like status code when the gateway sends update as request,
+//so the gateway is able to redeliver update which failed with transient error
+func (us *UpdatesSubscriber) handleMsg(msg *nats.Msg) {
+	update := &Update{}
+	var err error
+	if decodeErr := json.Unmarshal(msg.Data, update); decodeErr != nil {
+		err = newClientError(decodeErr)
+	} else {
+		err = us.dispatcher.Dispatch(update)
+	}
+	code := 200
+	if err != nil {
+		HandleError(err)
+		code = statusCode(err)
+	}
+	if msg.Reply != "" {
+		if respondErr := msg.Respond([]byte(strconv.Itoa(code))); respondErr != nil {
+			HandleError(errors.WithStack(respondErr))
+		}
+	}
+}
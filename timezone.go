@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const airTimeLayout = "02.01.2006 15:04"
+
+var utcOffsetRegexp = regexp.MustCompile(`^(?i:UTC|GMT)?([+-])(\d{1,2})(?::?(\d{2}))?$`)
+
+//loadTimezone func accepts IANA names like Europe/Moscow and UTC offsets like +3, UTC+03:00 or GMT-5.
+//Second result is the normalized name which should be stored and shown to user
+func loadTimezone(value string) (*time.Location, string, error) {
+	value = strings.TrimSpace(value)
+	if matches := utcOffsetRegexp.FindStringSubmatch(strings.ReplaceAll(value, " ", "")); matches != nil {
+		hours, _ := strconv.Atoi(matches[2])
+		minutes := 0
+		if matches[3] != "" {
+			minutes, _ = strconv.Atoi(matches[3])
+		}
+		if hours > 14 || minutes > 59 {
+			return nil, "", errors.Errorf("UTC offset %s is out of range", value)
+		}
+		offset := hours*3600 + minutes*60
+		if matches[1] == "-" {
+			offset = -offset
+		}
+		name := fmt.Sprintf("UTC%s%02d:%02d", matches[1], hours, minutes)
+		return time.FixedZone(name, offset), name, nil
+	}
+	if value == "" || value == "Local" {
+		return nil, "", errors.Errorf("Timezone %q is not supported", value)
+	}
+	location, loadErr := time.LoadLocation(value)
+	if loadErr != nil {
+		return nil, "", errors.WithStack(loadErr)
+	}
+	return location, location.String(), nil
+}
+
+//userTimezone func returns timezone of the user falling back to DefaultTimezone from settings
+func userTimezone(settings *Settings, timezone string) (*time.Location, string) {
+	if timezone != "" {
+		if location, name, err := loadTimezone(timezone); err == nil {
+			return location, name
+		}
+	}
+	if location, name, err := loadTimezone(settings.DefaultTimezone); err == nil {
+		return location, name
+	}
+	return time.UTC, time.UTC.String()
+}
+
+//formatAirTime func formats air time in timezone of the user, e.g. "02.01.2020 18:00 Europe/Moscow"
+func formatAirTime(airTime time.Time, location *time.Location, timezoneName string) string {
+	return airTime.In(location).Format(airTimeLayout) + " " + timezoneName
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"path"
+)
+
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+//WebhookHandler struct receives updates sent by Telegram to the webhook and passes them to UpdateDispatcher
+type WebhookHandler struct {
+	dispatcher UpdateDispatcher
+	settings   *Settings
+}
+
+func (wh *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !wh.isAuthorized(r) {
+		log.Printf("Unauthorized request to %s from %s\n", path.Dir(r.URL.Path), r.RemoteAddr)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	reqReader, logReqErr := logRequest(r)
+	if logReqErr != nil {
+		writeError(w, logReqErr)
+		return
+	}
+	decoder := json.NewDecoder(reqReader)
+	update := &Update{}
+	decodeErr := decoder.Decode(update)
+	if decodeErr != nil {
+		writeError(w, newClientError(decodeErr))
+		return
+	}
+	if err := wh.dispatcher.Dispatch(update); err != nil {
+		writeError(w, err)
+	}
+}
+
+//isAuthorized func checks webhook secret passed either in X-Telegram-Bot-Api-Secret-Token header or as the last path segment.
+//Every request is authorized when secret is not configured
+func (wh *WebhookHandler) isAuthorized(r *http.Request) bool {
+	secret := wh.settings.WebhookSecret
+	if secret == "" {
+		return true
+	}
+	if token := r.Header.Get(secretTokenHeader); token != "" {
+		return subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+	}
+	segment := path.Base(r.URL.Path)
+	return subtle.ConstantTimeCompare([]byte(segment), []byte(secret)) == 1
+}